@@ -0,0 +1,73 @@
+package gob
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Statement with arguments sent to database
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Builder renders statements upserting rows of batch without connection to database
+type Builder interface {
+	// Build statements resolving conflicts of rows on UpsertArgs.Keys with conflict action; empty rows are ignored.
+	// Returns ErrInvalidConflictTarget or ErrEmptykeys if conflict target of args is not supported
+	Build(args UpsertArgs) ([]Statement, error)
+}
+
+var (
+	buildersMu sync.RWMutex
+
+	// builders of providers; builtin providers execute statements of their builder
+	builders = map[DBProvider]Builder{
+		DBProviderPg:        &pg{},
+		DBProviderCockroach: &pg{dialect: pgDialectCockroach},
+		DBProviderYugabyte:  &pg{dialect: pgDialectYugabyte},
+		DBProviderMySQL:     &sqlDB{dialect: DialectMySQL},
+		DBProviderMariaDB:   &sqlDB{dialect: DialectMariaDB},
+		DBProviderTiDB:      &sqlDB{dialect: DialectTiDB},
+		DBProviderSQLite:    &sqlDB{dialect: DialectSQLite},
+		DBProviderMSSQL:     &mssql{},
+		DBProviderCassandra: &cassy{},
+	}
+)
+
+// RegisterBuilder makes statement builder of provider available to NewBuilder and WithDryRun, e.g. NewSQLBuilder
+// of dialect of a provider registered with SQLFactory; panics if builder is nil or RegisterBuilder is called twice
+// with the same name
+func RegisterBuilder(name DBProvider, builder Builder) {
+	buildersMu.Lock()
+	defer buildersMu.Unlock()
+
+	if builder == nil {
+		panic("gob: RegisterBuilder builder is nil")
+	}
+
+	if _, dup := builders[name]; dup {
+		panic(fmt.Sprintf("gob: RegisterBuilder called twice for provider %s", name))
+	}
+
+	builders[name] = builder
+}
+
+// NewBuilder returns statement builder of provider; returns error wrapping ErrUnsupported for providers
+// without builder registered
+func NewBuilder(provider DBProvider) (Builder, error) {
+	buildersMu.RLock()
+	defer buildersMu.RUnlock()
+
+	builder, ok := builders[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w build statements of provider %s", ErrUnsupported, provider)
+	}
+
+	return builder, nil
+}
+
+// NewSQLBuilder returns statement builder of generic SQL provider with dialect
+func NewSQLBuilder(dialect Dialect) Builder {
+	return &sqlDB{dialect: dialect}
+}
@@ -0,0 +1,173 @@
+package gob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	args := UpsertArgs{
+		Model:          "students",
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+		Rows:           []Row{{"name": "name-0", "age": 0}, {}, {"name": "name-1", "age": 1}},
+	}
+
+	tests := []struct {
+		provider DBProvider
+		want     []Statement
+	}{
+		{
+			provider: DBProviderPg,
+			want: []Statement{
+				{SQL: "INSERT INTO students(age,name) VALUES($1,$2) ON CONFLICT (name) DO UPDATE SET age=$1", Args: []interface{}{0, "name-0"}},
+				{SQL: "INSERT INTO students(age,name) VALUES($1,$2) ON CONFLICT (name) DO UPDATE SET age=$1", Args: []interface{}{1, "name-1"}},
+			},
+		},
+		{
+			provider: DBProviderCockroach,
+			want: []Statement{
+				{SQL: "INSERT INTO students(age,name) VALUES($1,$2) ON CONFLICT (name) DO UPDATE SET age=$1", Args: []interface{}{0, "name-0"}},
+				{SQL: "INSERT INTO students(age,name) VALUES($1,$2) ON CONFLICT (name) DO UPDATE SET age=$1", Args: []interface{}{1, "name-1"}},
+			},
+		},
+		{
+			provider: DBProviderSQLite,
+			want: []Statement{
+				{SQL: `INSERT INTO students("age","name") VALUES(?,?),(?,?) ON CONFLICT(name) DO UPDATE SET "age"=excluded."age"`, Args: []interface{}{0, "name-0", 1, "name-1"}},
+			},
+		},
+		{
+			provider: DBProviderMSSQL,
+			want: []Statement{
+				{SQL: "SELECT TOP 0 age,name INTO #gob_students FROM students UNION ALL SELECT age,name FROM students WHERE 1=0"},
+				{SQL: "INSERT INTO #gob_students (age,name) VALUES (@p1,@p2),(@p3,@p4)", Args: []interface{}{0, "name-0", 1, "name-1"}},
+				{SQL: "IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'age',N'name')) SET IDENTITY_INSERT students ON"},
+				{SQL: "MERGE INTO students WITH (HOLDLOCK) AS target USING #gob_students AS source ON (target.name=source.name) WHEN MATCHED THEN UPDATE SET target.age=source.age WHEN NOT MATCHED THEN INSERT (age,name) VALUES (source.age,source.name);"},
+				{SQL: "IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'age',N'name')) SET IDENTITY_INSERT students OFF"},
+				{SQL: "DROP TABLE #gob_students"},
+			},
+		},
+		{
+			provider: DBProviderCassandra,
+			want: []Statement{
+				{SQL: "INSERT INTO students (age,name) VALUES(?,?)", Args: []interface{}{0, "name-0"}},
+				{SQL: "INSERT INTO students (age,name) VALUES(?,?)", Args: []interface{}{1, "name-1"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.provider), func(t *testing.T) {
+			builder, err := NewBuilder(test.provider)
+			if err != nil {
+				t.Fatalf("builder err: %v", err)
+			}
+
+			got, err := builder.Build(args)
+			if err != nil {
+				t.Fatalf("build err: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("statements got: %+v want: %+v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("dialect", func(t *testing.T) {
+		got, err := NewSQLBuilder(testDialect{}).Build(args)
+		if err != nil {
+			t.Fatalf("build err: %v", err)
+		}
+
+		want := []Statement{{SQL: "students[age name][($1,$2) ($3,$4)]", Args: []interface{}{0, "name-0", 1, "name-1"}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("statements got: %+v want: %+v", got, want)
+		}
+	})
+
+	t.Run("mssqlParams", func(t *testing.T) {
+		builder, _ := NewBuilder(DBProviderMSSQL)
+		got, err := builder.Build(UpsertArgs{
+			Model:          "students",
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+			Rows:           testGenStudentRowsMySQL(1500),
+		})
+		if err != nil {
+			t.Fatalf("build err: %v", err)
+		}
+
+		// 1500 rows of 5 columns split into inserts of 420 rows by limit of 2100 parameters
+		inserts := got[1 : len(got)-4]
+		if len(inserts) != 4 {
+			t.Fatalf("inserts got: %d want: 4", len(inserts))
+		}
+
+		params := 0
+		for _, statement := range inserts {
+			if len(statement.Args) > mssqlMaxParams {
+				t.Fatalf("params of insert got: %d want: <= %d", len(statement.Args), mssqlMaxParams)
+			}
+			params = params + len(statement.Args)
+		}
+
+		if params != 1500*5 {
+			t.Fatalf("params of inserts got: %d want: %d", params, 1500*5)
+		}
+	})
+
+	t.Run("invalidConflictTarget", func(t *testing.T) {
+		builder, _ := NewBuilder(DBProviderPg)
+		if _, err := builder.Build(UpsertArgs{Model: "students", ConflictAction: ConflictActionUpdate, Rows: args.Rows}); !errors.Is(err, ErrEmptykeys) {
+			t.Fatalf("build without keys got: %v want: %v", err, ErrEmptykeys)
+		}
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		RegisterBuilder("test-builder", NewSQLBuilder(testDialect{}))
+		builder, err := NewBuilder("test-builder")
+		if err != nil {
+			t.Fatalf("builder err: %v", err)
+		}
+
+		got, err := builder.Build(args)
+		if err != nil {
+			t.Fatalf("build err: %v", err)
+		}
+
+		want := []Statement{{SQL: "students[age name][($1,$2) ($3,$4)]", Args: []interface{}{0, "name-0", 1, "name-1"}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("statements got: %+v want: %+v", got, want)
+		}
+
+		var buf bytes.Buffer
+		gob, err := New(WithDBProvider("test-builder"), WithDryRun(&buf, false))
+		if err != nil {
+			t.Fatalf("init gob with dry run of registered builder err: %v", err)
+		}
+		defer gob.Close()
+
+		if err := gob.Upsert(context.Background(), args); err != nil || !strings.Contains(buf.String(), want[0].SQL) {
+			t.Fatalf("dry run got: %s, %v want: %s", buf.String(), err, want[0].SQL)
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("register builder twice; want panic")
+			}
+		}()
+		RegisterBuilder("test-builder", NewSQLBuilder(testDialect{}))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		if _, err := NewBuilder(DBProviderMemory); !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("builder got: %v want: %v", err, ErrUnsupported)
+		}
+	})
+}
@@ -3,10 +3,15 @@ package gob
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/csmadhu/gob/utils"
 	"github.com/gocql/gocql"
 )
 
@@ -17,22 +22,33 @@ const (
 
 type cassy struct {
 	*gocql.Session
+	keyspace string // keyspace of session
+	observer observer
+	hosts    *cassyHostTracker
 }
 
-func newCassandra(args connArgs) (db, error) {
+func init() {
+	Register(DBProviderCassandra, newCassandra)
+}
+
+func newCassandra(args ConnArgs) (Provider, error) {
 	var (
 		c       cassy
 		err     error
 		cluster *gocql.ClusterConfig
 	)
 
-	cluster, err = parseCassyConnString(args.connStr)
+	cluster, err = parseCassyConnString(args.ConnStr)
 	if err != nil {
 		return nil, err
 	}
 
-	cluster.Timeout = args.connLifeTime
-	cluster.NumConns = args.openConns
+	cluster.Timeout = args.ConnLifeTime
+	cluster.NumConns = args.OpenConns
+	c.keyspace = cluster.Keyspace
+	c.observer = newObserver(args, "Cassandra")
+	c.hosts = newCassyHostTracker(cluster.PoolConfig.HostSelectionPolicy)
+	cluster.PoolConfig.HostSelectionPolicy = c.hosts
 
 	c.Session, err = cluster.CreateSession()
 	if err != nil {
@@ -160,23 +176,113 @@ func cassyHostSelectionPolicy(value string) gocql.HostSelectionPolicy {
 	return gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
 }
 
-func (db *cassy) upsert(ctx context.Context, upsertArgs UpsertArgs) error {
+// cassyHostTracker is host selection policy tracking state of hosts of ring notified to policy
+type cassyHostTracker struct {
+	gocql.HostSelectionPolicy
+
+	mu    sync.Mutex
+	hosts map[string]bool // up by address of host
+}
+
+func newCassyHostTracker(policy gocql.HostSelectionPolicy) *cassyHostTracker {
+	return &cassyHostTracker{HostSelectionPolicy: policy, hosts: make(map[string]bool)}
+}
+
+func (t *cassyHostTracker) set(host *gocql.HostInfo, up bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.hosts[net.JoinHostPort(host.ConnectAddress().String(), strconv.Itoa(host.Port()))] = up
+}
+
+func (t *cassyHostTracker) AddHost(host *gocql.HostInfo) {
+	t.set(host, host.IsUp())
+	t.HostSelectionPolicy.AddHost(host)
+}
+
+func (t *cassyHostTracker) RemoveHost(host *gocql.HostInfo) {
+	t.mu.Lock()
+	delete(t.hosts, net.JoinHostPort(host.ConnectAddress().String(), strconv.Itoa(host.Port())))
+	t.mu.Unlock()
+
+	t.HostSelectionPolicy.RemoveHost(host)
+}
+
+func (t *cassyHostTracker) HostUp(host *gocql.HostInfo) {
+	t.set(host, true)
+	t.HostSelectionPolicy.HostUp(host)
+}
+
+func (t *cassyHostTracker) HostDown(host *gocql.HostInfo) {
+	t.set(host, false)
+	t.HostSelectionPolicy.HostDown(host)
+}
+
+// count hosts up and down
+func (t *cassyHostTracker) count() (up, down int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, isUp := range t.hosts {
+		if isUp {
+			up++
+		} else {
+			down++
+		}
+	}
+
+	return up, down
+}
+
+func (db *cassy) Upsert(ctx context.Context, upsertArgs UpsertArgs) error {
 	if len(upsertArgs.Rows) == 0 {
 		return nil
 	}
 
+	statements, err := db.Build(upsertArgs)
+	if err != nil {
+		return err
+	}
+
+	// statements are applied on execution without transaction
+	_, span := db.observer.commit(ctx, upsertArgs.Model, len(statements))
+	err = db.execute(ctx, statements)
+	endSpan(span, err)
+	return err
+}
+
+func (db *cassy) execute(ctx context.Context, statements []Statement) error {
+	for _, statement := range statements {
+		if err := db.observer.wait(ctx, 1); err != nil {
+			return err
+		}
+
+		stmtCtx, done := db.observer.statementContext(ctx)
+		t0 := time.Now()
+		err := db.Query(statement.SQL, statement.Args...).WithContext(stmtCtx).Exec()
+		db.observer.statement(ctx, statement.SQL, t0)
+		if err != nil {
+			return done(fmt.Errorf("gob: execute sql '%s' on Cassandra: %w", statement.SQL, err))
+		}
+		done(nil)
+	}
+
+	return nil
+}
+
+// Build statements of rows of batch
+func (db *cassy) Build(upsertArgs UpsertArgs) ([]Statement, error) {
+	var statements []Statement
 	for _, row := range upsertArgs.Rows {
 		if row.Len() == 0 {
 			continue // ignore empty row
 		}
 
 		sql, args := db.rowToCQL(row, upsertArgs)
-		if err := db.Query(sql, args...).Exec(); err != nil {
-			return fmt.Errorf("gob: execute sql '%s' on Cassandra: %w", sql, err)
-		}
+		statements = append(statements, Statement{SQL: sql, Args: args})
 	}
 
-	return nil
+	return statements, nil
 }
 
 func (db *cassy) rowToCQL(row Row, upsertArgs UpsertArgs) (sql string, args []interface{}) {
@@ -211,6 +317,228 @@ func (db *cassy) rowToCQL(row Row, upsertArgs UpsertArgs) (sql string, args []in
 	return strings.TrimSpace(sql), args
 }
 
-func (db *cassy) close() {
-	db.Close()
+// Metadata of model read from system_schema instead of the schema cached by gocql, which is refreshed only after
+// schema events following DDL
+func (db *cassy) Metadata(ctx context.Context, model string) (*Metadata, error) {
+	keyspace, table := splitModel(model)
+	if keyspace == "" {
+		keyspace = db.keyspace
+	}
+
+	var (
+		partitionKey, clusteringKey, regular []cassyColumn
+		column                               cassyColumn
+	)
+
+	iter := db.Query(cassyColumnsSQL, keyspace, table).WithContext(ctx).Iter()
+	for iter.Scan(&column.name, &column.kind, &column.position, &column.typ) {
+		switch column.kind {
+		case "partition_key":
+			partitionKey = append(partitionKey, column)
+		case "clustering":
+			clusteringKey = append(clusteringKey, column)
+		default:
+			regular = append(regular, column)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("gob: read columns of model '%s' on Cassandra: %w", model, err)
+	}
+
+	if len(partitionKey) == 0 {
+		return nil, fmt.Errorf("%w '%s' in keyspace '%s' on Cassandra", ErrModelNotFound, table, keyspace)
+	}
+
+	// keys in key order followed by other columns by name like gocql
+	sort.Slice(partitionKey, func(i, j int) bool { return partitionKey[i].position < partitionKey[j].position })
+	sort.Slice(clusteringKey, func(i, j int) bool { return clusteringKey[i].position < clusteringKey[j].position })
+	sort.Slice(regular, func(i, j int) bool { return regular[i].name < regular[j].name })
+
+	metadata := &Metadata{Model: model}
+	for _, column := range append(append(partitionKey, clusteringKey...), regular...) {
+		key := column.kind == "partition_key" || column.kind == "clustering"
+		metadata.Columns = append(metadata.Columns, Column{
+			Name:     column.name,
+			Type:     column.typ,
+			Nullable: !key,
+			typ:      cassyColumnType(column.typ),
+		})
+
+		if key {
+			metadata.PrimaryKey = append(metadata.PrimaryKey, column.name)
+		}
+	}
+
+	return metadata, nil
+}
+
+// cassyColumnsSQL reads columns of table from schema of cluster
+const cassyColumnsSQL = "SELECT column_name, kind, position, type FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?"
+
+// cassyColumn of system_schema.columns
+type cassyColumn struct {
+	name     string
+	kind     string // partition_key, clustering, regular or static
+	position int    // in key; -1 for other columns
+	typ      string // CQL type e.g. frozen<list<text>>
+}
+
+// cassyColumnType maps CQL type of system_schema to column type
+func cassyColumnType(typ string) columnType {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if strings.HasPrefix(typ, "frozen<") {
+		typ = strings.TrimSuffix(strings.TrimPrefix(typ, "frozen<"), ">")
+	}
+
+	if idx := strings.Index(typ, "<"); idx >= 0 {
+		typ = typ[:idx]
+	}
+
+	switch typ {
+	case "ascii", "text", "varchar":
+		return columnType{kind: kindString}
+	case "tinyint":
+		return columnType{kind: kindInt, bits: 8}
+	case "smallint":
+		return columnType{kind: kindInt, bits: 16}
+	case "int":
+		return columnType{kind: kindInt, bits: 32}
+	case "bigint", "counter", "varint":
+		return columnType{kind: kindInt, bits: 64}
+	case "float", "double", "decimal":
+		return columnType{kind: kindFloat}
+	case "boolean":
+		return columnType{kind: kindBool}
+	case "timestamp":
+		return columnType{kind: kindTime}
+	case "date":
+		return columnType{kind: kindDate}
+	case "uuid", "timeuuid":
+		return columnType{kind: kindUUID}
+	case "blob":
+		return columnType{kind: kindBytes}
+	case "list", "set":
+		return columnType{kind: kindList}
+	case "map":
+		return columnType{kind: kindMap}
+	}
+
+	return columnType{kind: kindAny}
+}
+
+// cassyTypeName returns CQL type of column type
+func cassyTypeName(typ columnType) string {
+	switch typ.kind {
+	case kindInt:
+		switch typ.bits {
+		case 8:
+			return "tinyint"
+		case 16:
+			return "smallint"
+		case 32:
+			return "int"
+		}
+		return "bigint"
+	case kindFloat:
+		return "double"
+	case kindBool:
+		return "boolean"
+	case kindTime:
+		return "timestamp"
+	case kindDate:
+		return "date"
+	case kindUUID:
+		return "uuid"
+	case kindBytes:
+		return "blob"
+	case kindList:
+		if typ.elem != nil {
+			return fmt.Sprintf("list<%s>", cassyCollectionTypeName(*typ.elem))
+		}
+	case kindMap:
+		if typ.key != nil && typ.elem != nil {
+			return fmt.Sprintf("map<%s, %s>", cassyCollectionTypeName(*typ.key), cassyCollectionTypeName(*typ.elem))
+		}
+	}
+
+	return "text"
+}
+
+// cassyCollectionTypeName returns CQL type of collection elements; nested collections are frozen
+func cassyCollectionTypeName(typ columnType) string {
+	name := cassyTypeName(typ)
+	if typ.kind == kindList || typ.kind == kindMap {
+		return fmt.Sprintf("frozen<%s>", name)
+	}
+
+	return name
+}
+
+func (db *cassy) AddColumn(ctx context.Context, model, column string, value interface{}) error {
+	return db.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD %s %s", model, column, cassyTypeName(inferType(value))))
+}
+
+// exec DDL and wait for nodes to agree on schema so metadata read next is current
+func (db *cassy) exec(ctx context.Context, sql string) error {
+	if err := db.Query(sql).WithContext(ctx).Exec(); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on Cassandra: %w", sql, err)
+	}
+
+	if err := db.AwaitSchemaAgreement(ctx); err != nil {
+		return fmt.Errorf("gob: await schema agreement after '%s' on Cassandra: %w", sql, err)
+	}
+
+	return nil
+}
+
+// Ping queries local node of session
+func (db *cassy) Ping(ctx context.Context) error {
+	if err := db.Query("SELECT release_version FROM system.local").WithContext(ctx).Exec(); err != nil {
+		return fmt.Errorf("gob: ping Cassandra: %w", err)
+	}
+
+	return nil
+}
+
+// cassyCreateModelSQL returns CREATE TABLE statement of schema with partition and clustering keys
+func cassyCreateModelSQL(schema modelSchema) string {
+	var defs []string
+	for _, column := range schema.columns {
+		defs = append(defs, fmt.Sprintf("%s %s", column.name, cassyTypeName(column.typ)))
+	}
+
+	var (
+		partitionSet = utils.NewStringSet(schema.partitionKeys...)
+		primaryKey   = []string{fmt.Sprintf("(%s)", strings.Join(schema.partitionKeys, ", "))}
+	)
+
+	if len(schema.partitionKeys) == 1 {
+		primaryKey[0] = schema.partitionKeys[0]
+	}
+
+	for _, key := range schema.keys {
+		if !partitionSet.Contains(key) {
+			primaryKey = append(primaryKey, key)
+		}
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s))",
+		schema.model,
+		strings.Join(defs, ", "),
+		strings.Join(primaryKey, ", "),
+	)
+}
+
+func (db *cassy) CreateModel(ctx context.Context, args UpsertArgs, sampleSize int) error {
+	schema, err := inferModelSchema(args, sampleSize)
+	if err != nil {
+		return err
+	}
+
+	return db.exec(ctx, cassyCreateModelSQL(schema))
+}
+
+func (db *cassy) Close() {
+	db.Session.Close()
 }
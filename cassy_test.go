@@ -1,8 +1,11 @@
 package gob
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"reflect"
 	"testing"
 	"time"
@@ -13,32 +16,26 @@ import (
 var (
 	testCassyDB         *gocql.Session
 	testCassyConnString = "cassandra://localhost:9042/gob?consistency=quorum&compressor=snappy&tokenAware=true"
-	testCassyArgs       = connArgs{
-		connStr:      testCassyConnString,
-		openConns:    1,
-		connLifeTime: 3 * time.Second,
+	testCassyArgs       = ConnArgs{
+		ConnStr:      testCassyConnString,
+		OpenConns:    1,
+		ConnLifeTime: 3 * time.Second,
 	}
 )
 
-func init() {
-	if err := setupCassyDB(); err != nil {
-		log.Fatalf("setup cassandra; err: %v", err)
-	}
-}
-
 func setupCassyDB() error {
-	cluster, err := parseCassyConnString(testCassyArgs.connStr)
+	cluster, err := parseCassyConnString(testCassyArgs.ConnStr)
 	if err != nil {
 		return err
 	}
 
 	cluster.Keyspace = "system"
-	cluster.Timeout = testCassyArgs.connLifeTime
-	cluster.NumConns = testCassyArgs.openConns
+	cluster.Timeout = testCassyArgs.ConnLifeTime
+	cluster.NumConns = testCassyArgs.OpenConns
 
 	session, err := cluster.CreateSession()
 	if err != nil {
-		return fmt.Errorf("connect to Cassnadra with keyspace %s: %w", cluster.Keyspace, err)
+		return fmt.Errorf("%w: connect to Cassandra with keyspace %s: %v", errTestUnreachable, cluster.Keyspace, err)
 	}
 
 	var (
@@ -225,25 +222,133 @@ func TestParseCassyConnString(t *testing.T) {
 }
 
 func TestNewCassandra(t *testing.T) {
+	testSetup(t, setupCassyDB)
 	db, err := newCassandra(testCassyArgs)
 	if err != nil {
 		t.Fatalf("init Cassandra; err: %v", err)
 	}
 
-	db.close()
+	db.Close()
 }
 
 func TestUpsertCassy(t *testing.T) {
-	setupCassyDB()
+	testSetup(t, setupCassyDB)
 	db, err := newCassandra(testCassyArgs)
 	if err != nil {
 		t.Fatalf("init Cassandra err: %v", err)
 	}
-	defer db.close()
+	defer db.Close()
 
 	testUpsertDB(t, db, testGenStudentRowsCassy, testVerifyStudentRowsCassy)
 }
 
+func TestMetadataCassy(t *testing.T) {
+	testSetup(t, setupCassyDB)
+	db, err := newCassandra(testCassyArgs)
+	if err != nil {
+		t.Fatalf("init Cassandra err: %v", err)
+	}
+	defer db.Close()
+
+	t.Run("students", func(t *testing.T) {
+		got, err := db.(MetadataProvider).Metadata(context.Background(), "students")
+		if err != nil {
+			t.Fatalf("metadata err: %v", err)
+		}
+
+		if want := []string{"name"}; !reflect.DeepEqual(got.PrimaryKey, want) {
+			t.Fatalf("primaryKey got: %v want: %v", got.PrimaryKey, want)
+		}
+
+		var columns []string
+		for _, column := range got.Columns {
+			columns = append(columns, column.Name)
+		}
+
+		if want := []string{"name", "age", "birthday", "profile", "subjects"}; !reflect.DeepEqual(columns, want) {
+			t.Fatalf("columns got: %v want: %v", columns, want)
+		}
+	})
+
+	t.Run("modelNotFound", func(t *testing.T) {
+		if _, err := db.(MetadataProvider).Metadata(context.Background(), "teachers"); !errors.Is(err, ErrModelNotFound) {
+			t.Fatalf("metadata of unknown model got: %v want: %v", err, ErrModelNotFound)
+		}
+	})
+}
+
+func TestCreateModelCassy(t *testing.T) {
+	testSetup(t, setupCassyDB)
+	gob, err := New(WithDBProvider(DBProviderCassandra), WithDBConnStr(testCassyConnString), WithCreateModel(10))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	// metadata is read right after CREATE TABLE
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "teachers",
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+		Rows:           []Row{{"name": "name-0", "age": 30}, {"name": "name-1", "age": 40}},
+	}); err != nil {
+		t.Fatalf("upsert rows to new model err: %v", err)
+	}
+
+	metadata, err := gob.Metadata(context.Background(), "teachers")
+	if err != nil {
+		t.Fatalf("metadata err: %v", err)
+	}
+
+	var columns []string
+	for _, column := range metadata.Columns {
+		columns = append(columns, column.Name)
+	}
+
+	if want := []string{"name", "age"}; !reflect.DeepEqual(columns, want) || !reflect.DeepEqual(metadata.PrimaryKey, []string{"name"}) {
+		t.Fatalf("columns and primary key got: %v, %v want: %v, [name]", columns, metadata.PrimaryKey, want)
+	}
+}
+
+func TestAddColumnCassy(t *testing.T) {
+	testSetup(t, setupCassyDB)
+	gob, err := New(WithDBProvider(DBProviderCassandra), WithDBConnStr(testCassyConnString), WithUnknownColumnPolicy("students", UnknownColumnAdd))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	// metadata is read right after ALTER TABLE
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+		Rows:           []Row{{"name": "name-0", "nickname": "nickname-0"}},
+	}); err != nil {
+		t.Fatalf("upsert rows with new column err: %v", err)
+	}
+
+	var nickname string
+	if err := testCassyDB.Query("SELECT nickname FROM students WHERE name = ?", "name-0").Scan(&nickname); err != nil || nickname != "nickname-0" {
+		t.Fatalf("nickname got: %q, %v want: nickname-0", nickname, err)
+	}
+}
+
+func TestCassyColumnType(t *testing.T) {
+	for typ, want := range map[string]columnType{
+		"text":                   {kind: kindString},
+		"int":                    {kind: kindInt, bits: 32},
+		"timestamp":              {kind: kindTime},
+		"list<text>":             {kind: kindList},
+		"frozen<map<text, int>>": {kind: kindMap},
+		"duration":               {kind: kindAny},
+	} {
+		if got := cassyColumnType(typ); got != want {
+			t.Fatalf("column type of %s got: %+v want: %+v", typ, got, want)
+		}
+	}
+}
+
 func TestRowToCQL(t *testing.T) {
 	wantSQLs := []string{
 		"INSERT INTO students (age,birthday,name,profile,subjects) VALUES(?,?,?,?,?)",
@@ -258,3 +363,28 @@ func TestRowToCQL(t *testing.T) {
 	cassy := &cassy{}
 	testRowToSQL(t, testGenStudentRowsCassy, cassy.rowToCQL, wantSQLs, wantArgs)
 }
+
+func TestCassyHostTracker(t *testing.T) {
+	tracker := newCassyHostTracker(gocql.RoundRobinHostPolicy())
+
+	var hosts []*gocql.HostInfo
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		host := (&gocql.HostInfo{}).SetConnectAddress(net.ParseIP(ip))
+		hosts = append(hosts, host)
+		tracker.AddHost(host)
+	}
+
+	tracker.HostDown(hosts[1])
+	tracker.HostDown(hosts[2])
+	tracker.HostUp(hosts[2])
+	tracker.RemoveHost(hosts[0])
+
+	if up, down := tracker.count(); up != 1 || down != 1 {
+		t.Fatalf("hosts up and down got: %d, %d want: 1, 1", up, down)
+	}
+
+	db := &cassy{hosts: tracker}
+	if stats := db.Stats(); stats != (PoolStats{HostsUp: 1, HostsDown: 1}) {
+		t.Fatalf("stats got: %+v want: 1 host up and 1 down", stats)
+	}
+}
@@ -0,0 +1,209 @@
+package gob
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Checkpoint of load after a committed batch
+type Checkpoint struct {
+	Load   string    `json:"load"`
+	Model  string    `json:"model"`
+	Offset int       `json:"offset"` // rows of load committed
+	Cursor string    `json:"cursor"` // cursor of row source returned by UpsertArgs.Cursor
+	Time   time.Time `json:"time"`   // of commit
+}
+
+// CheckpointStore records last checkpoint per load
+type CheckpointStore interface {
+	// Load returns last checkpoint of load; nil if none
+	Load(ctx context.Context, load string) (*Checkpoint, error)
+
+	// Save checkpoint replacing last checkpoint of its load
+	Save(ctx context.Context, checkpoint Checkpoint) error
+}
+
+// fileCheckpointStore writes checkpoint of each load to a JSON file in directory after batch is committed
+type fileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore returns store writing checkpoint of each load to <load>.json in dir. Checkpoints are
+// written after batches are committed; a batch committed before a crash while writing is upserted again on resume
+func NewFileCheckpointStore(dir string) (CheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("gob: create directory %s: %w", dir, err)
+	}
+
+	return &fileCheckpointStore{dir: dir}, nil
+}
+
+func (store *fileCheckpointStore) path(load string) string {
+	return filepath.Join(store.dir, url.PathEscape(load)+".json")
+}
+
+func (store *fileCheckpointStore) Load(ctx context.Context, load string) (*Checkpoint, error) {
+	b, err := ioutil.ReadFile(store.path(load))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("gob: read checkpoint of load '%s': %w", load, err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		return nil, fmt.Errorf("gob: decode checkpoint of load '%s': %w", load, err)
+	}
+
+	return &checkpoint, nil
+}
+
+// Save writes checkpoint to temp file renamed over last checkpoint
+func (store *fileCheckpointStore) Save(ctx context.Context, checkpoint Checkpoint) error {
+	b, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("gob: encode checkpoint of load '%s': %w", checkpoint.Load, err)
+	}
+
+	path := store.path(checkpoint.Load)
+	if err := ioutil.WriteFile(path+".tmp", b, 0644); err != nil {
+		return fmt.Errorf("gob: write checkpoint of load '%s': %w", checkpoint.Load, err)
+	}
+
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("gob: write checkpoint of load '%s': %w", checkpoint.Load, err)
+	}
+
+	return nil
+}
+
+// checkpointTable matches plain table name optionally qualified by schema
+var checkpointTable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// tableCheckpointStore saves checkpoints in table of target database in transaction of batch
+type tableCheckpointStore struct {
+	table string
+	db    txDB // provider of Gob bound by New
+}
+
+// NewTableCheckpointStore returns store of checkpoints in table of target database created if not exists;
+// checkpoints are saved in the transaction of their batch. Supported by PostgreSQL, MySQL, SQLite and
+// providers of Dialect
+func NewTableCheckpointStore(table string) (CheckpointStore, error) {
+	if !checkpointTable.MatchString(table) {
+		return nil, fmt.Errorf("gob: invalid checkpoint table: %s", table)
+	}
+
+	return &tableCheckpointStore{table: table}, nil
+}
+
+// bind store to provider and create table
+func (store *tableCheckpointStore) bind(ctx context.Context, provider Provider) error {
+	db, ok := provider.(txDB)
+	if !ok {
+		return fmt.Errorf("%w checkpoints in table %s", ErrUnsupported, store.table)
+	}
+
+	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (load_id VARCHAR(255) NOT NULL, model VARCHAR(255) NOT NULL, "+
+		"row_offset BIGINT NOT NULL, source_cursor TEXT NOT NULL, updated_at VARCHAR(64) NOT NULL, PRIMARY KEY (load_id))", store.table)
+	if err := db.exec(ctx, createSQL); err != nil {
+		return err
+	}
+
+	store.db = db
+	return nil
+}
+
+func (store *tableCheckpointStore) Load(ctx context.Context, load string) (*Checkpoint, error) {
+	if store.db == nil {
+		return nil, fmt.Errorf("gob: checkpoint table %s not bound to Gob", store.table)
+	}
+
+	var (
+		checkpoint = Checkpoint{Load: load}
+		offset     int64
+		updatedAt  string
+	)
+
+	selectSQL := fmt.Sprintf("SELECT model, row_offset, source_cursor, updated_at FROM %s WHERE load_id = %s", store.table, store.db.placeholder(1))
+	err := store.db.queryRow(ctx, selectSQL, load).Scan(&checkpoint.Model, &offset, &checkpoint.Cursor, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("gob: query checkpoint of load '%s': %w", load, err)
+	}
+
+	checkpoint.Offset = int(offset)
+	if checkpoint.Time, err = time.Parse(time.RFC3339Nano, updatedAt); err != nil {
+		return nil, fmt.Errorf("gob: parse time of checkpoint of load '%s': %w", load, err)
+	}
+
+	return &checkpoint, nil
+}
+
+// Save checkpoint outside of transaction of batch
+func (store *tableCheckpointStore) Save(ctx context.Context, checkpoint Checkpoint) error {
+	statements, err := store.statements(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := store.db.upsertTx(ctx, UpsertArgs{Model: store.table}, statements); err != nil {
+		return fmt.Errorf("gob: save checkpoint of load '%s': %w", checkpoint.Load, err)
+	}
+
+	return nil
+}
+
+// statements upserting checkpoint
+func (store *tableCheckpointStore) statements(checkpoint Checkpoint) ([]Statement, error) {
+	if store.db == nil {
+		return nil, fmt.Errorf("gob: checkpoint table %s not bound to Gob", store.table)
+	}
+
+	return store.db.Build(UpsertArgs{
+		Model:          store.table,
+		Keys:           []string{"load_id"},
+		ConflictAction: ConflictActionUpdate,
+		Rows: []Row{{
+			"load_id":       checkpoint.Load,
+			"model":         checkpoint.Model,
+			"row_offset":    int64(checkpoint.Offset),
+			"source_cursor": checkpoint.Cursor,
+			"updated_at":    checkpoint.Time.UTC().Format(time.RFC3339Nano),
+		}},
+	})
+}
+
+// checkpoint of load after first rows of args; statements saving checkpoint in transaction of batch if supported by store
+func (gob *Gob) checkpoint(args UpsertArgs, rows int) (*Checkpoint, []Statement, error) {
+	checkpoint := &Checkpoint{Load: args.Load, Model: args.Model, Offset: rows, Time: time.Now()}
+	if args.Cursor != nil {
+		checkpoint.Cursor = args.Cursor(rows)
+	}
+
+	// dry run does not save checkpoints
+	if gob.dryRun != nil {
+		return nil, nil, nil
+	}
+
+	if store, ok := gob.checkpoints.(*tableCheckpointStore); ok {
+		statements, err := store.statements(*checkpoint)
+		return checkpoint, statements, err
+	}
+
+	return checkpoint, nil, nil
+}
@@ -0,0 +1,164 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testCheckpointLoad upserts rows of load cancelling context after first batch, then resumes load
+func testCheckpointLoad(t *testing.T, gob *Gob, rows []Row) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	args := UpsertArgs{
+		Model:          "students",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+		Load:           "students-2020",
+		Cursor: func(rows int) string {
+			return fmt.Sprintf("msg-%d", rows)
+		},
+	}
+
+	gob.setProgress(func(progress Progress) {
+		cancel()
+	})
+
+	if err := gob.Upsert(ctx, args); !errors.Is(err, context.Canceled) {
+		t.Fatalf("upsert rows err got: %v want: %v", err, context.Canceled)
+	}
+
+	checkpoint, err := gob.Checkpoint(context.Background(), args.Load)
+	if err != nil {
+		t.Fatalf("checkpoint err: %v", err)
+	}
+
+	if checkpoint == nil || checkpoint.Model != "students" || checkpoint.Offset != 10 || checkpoint.Cursor != "msg-10" || checkpoint.Time.IsZero() {
+		t.Fatalf("checkpoint got: %+v want: offset 10 of students at msg-10", checkpoint)
+	}
+
+	var resumed []int
+	gob.setProgress(func(progress Progress) {
+		resumed = append(resumed, progress.Rows)
+	})
+
+	if err := gob.Resume(context.Background(), args); err != nil {
+		t.Fatalf("resume err: %v", err)
+	}
+
+	if want := []int{10, 15}; fmt.Sprint(resumed) != fmt.Sprint(want) {
+		t.Fatalf("rows of resumed batches got: %v want: %v", resumed, want)
+	}
+
+	if checkpoint, _ = gob.Checkpoint(context.Background(), args.Load); checkpoint.Offset != 25 || checkpoint.Cursor != "msg-25" {
+		t.Fatalf("checkpoint got: %+v want: offset 25 at msg-25", checkpoint)
+	}
+
+	// committed load is not upserted again
+	resumed = nil
+	if err := gob.Resume(context.Background(), args); err != nil || resumed != nil {
+		t.Fatalf("resume of committed load got: %v, %v want: no batches", err, resumed)
+	}
+
+	args.Model = "teachers"
+	if err := gob.Resume(context.Background(), args); err == nil {
+		t.Fatalf("resume load of other model; want err")
+	}
+}
+
+func TestCheckpointFile(t *testing.T) {
+	store, err := NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("init store; err: %v", err)
+	}
+
+	gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(10), WithCheckpoint(store))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	testCheckpointLoad(t, gob, testGenStudentRowsMySQL(25))
+
+	if got := gob.Provider().(*Memory).Len("students"); got != 25 {
+		t.Fatalf("len got: %d want: 25", got)
+	}
+}
+
+func TestCheckpointTable(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup sqlite; err: %v", err)
+	}
+
+	if _, err := testSQLiteDB.Exec("DROP TABLE IF EXISTS gob_checkpoints"); err != nil {
+		t.Fatalf("drop table; err: %v", err)
+	}
+
+	store, err := NewTableCheckpointStore("gob_checkpoints")
+	if err != nil {
+		t.Fatalf("init store; err: %v", err)
+	}
+
+	gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr), WithBatchSize(10), WithCheckpoint(store))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	rows := testGenStudentRowsSQLite(25)
+	testCheckpointLoad(t, gob, rows)
+	testVerifyStudentRowsSQLite(t, rows)
+
+	t.Run("rollback", func(t *testing.T) {
+		// checkpoint is rolled back with failed batch
+		err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           []Row{{"name": "name-0", "unknown": 1}},
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+			Load:           "students-2021",
+		})
+		if err == nil {
+			t.Fatalf("upsert unknown column; want err")
+		}
+
+		if checkpoint, err := gob.Checkpoint(context.Background(), "students-2021"); err != nil || checkpoint != nil {
+			t.Fatalf("checkpoint of failed batch got: %+v, %v want: none", checkpoint, err)
+		}
+	})
+}
+
+func TestCheckpointErrors(t *testing.T) {
+	store, _ := NewTableCheckpointStore("gob_checkpoints")
+	if _, err := New(WithDBProvider(DBProviderMemory), WithCheckpoint(store)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("init gob with table store of memory err got: %v want: %v", err, ErrUnsupported)
+	}
+
+	if _, err := NewTableCheckpointStore("gob_checkpoints; DROP TABLE students"); err == nil {
+		t.Fatalf("init store with invalid table; want err")
+	}
+
+	gob, err := New(WithDBProvider(DBProviderMemory))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	err = gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           testGenStudentRowsMySQL(1),
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+		Load:           "students-2020",
+	})
+	if !errors.Is(err, ErrNoCheckpointStore) {
+		t.Fatalf("upsert load without store err got: %v want: %v", err, ErrNoCheckpointStore)
+	}
+
+	if _, err := gob.Checkpoint(context.Background(), ""); !errors.Is(err, ErrEmptyLoad) {
+		t.Fatalf("checkpoint of empty load err got: %v want: %v", err, ErrEmptyLoad)
+	}
+}
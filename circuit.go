@@ -0,0 +1,132 @@
+package gob
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// states of circuitBreaker
+const (
+	circuitClosed   = "closed"    // upserts pass
+	circuitOpen     = "open"      // upserts fail fast until cooldown elapses
+	circuitHalfOpen = "half-open" // health probe in progress, upserts fail fast
+)
+
+// circuitBreaker fails upserts fast after consecutive connectivity failures and probes database after cooldown;
+// safe for concurrent use
+type circuitBreaker struct {
+	threshold int           // consecutive failures opening circuit
+	cooldown  time.Duration // of open circuit before health probe
+	logger    Logger
+
+	mu       sync.Mutex
+	state    string
+	failures int       // consecutive connectivity failures
+	openedAt time.Time // of open circuit
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, logger Logger) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, logger: logger, state: circuitClosed}
+}
+
+// circuitProbeTimeout of health probe of half-open circuit
+const circuitProbeTimeout = 5 * time.Second
+
+// connFailure reports whether err is a failure of connections to database including timeouts of dials; timeouts
+// of callers and of WithTimeouts are not, slow queries of a healthy database would open the circuit for all callers.
+// ErrConnClosed of a closed Gob is not either. Kept apart from ErrorClassConn of metrics
+func connFailure(err error) bool {
+	var (
+		opErr  *net.OpError
+		netErr net.Error
+	)
+
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return true
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &netErr):
+		return !netErr.Timeout()
+	}
+
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
+
+// allow upsert or fail with ErrCircuitOpen; first upsert after cooldown half-opens circuit and probes database
+// with its own timeout detached from ctx of caller, closing circuit if probe succeeds. A nil probe lets the upsert
+// through as the probe
+func (b *circuitBreaker) allow(ctx context.Context, probe func(ctx context.Context) error) error {
+	b.mu.Lock()
+	switch b.state {
+	case circuitClosed:
+		b.mu.Unlock()
+		return nil
+	case circuitHalfOpen:
+		b.mu.Unlock()
+		return fmt.Errorf("%w probing database", ErrCircuitOpen)
+	}
+
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+		b.mu.Unlock()
+		return fmt.Errorf("%w after %d connectivity failures; probe in %v", ErrCircuitOpen, b.threshold, wait)
+	}
+
+	b.state = circuitHalfOpen
+	b.mu.Unlock()
+
+	var err error
+	if probe != nil {
+		probeCtx, cancel := context.WithTimeout(context.Background(), circuitProbeTimeout)
+		err = probe(probeCtx)
+		cancel()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.state, b.openedAt = circuitOpen, time.Now()
+		b.logger.Log(ctx, LevelWarn, "circuit open",
+			Field{"cooldown", b.cooldown},
+			Field{"error", err},
+		)
+		return fmt.Errorf("%w probe failed: %v", ErrCircuitOpen, err)
+	}
+
+	b.state, b.failures = circuitClosed, 0
+	b.logger.Log(ctx, LevelInfo, "circuit closed")
+	return nil
+}
+
+// record result of upsert; connectivity failures open circuit after threshold, other results reset the failures.
+// Canceled and timed out upserts are ignored
+func (b *circuitBreaker) record(ctx context.Context, err error) {
+	if !connFailure(err) && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || !connFailure(err) {
+		b.failures = 0
+		return
+	}
+
+	b.failures = b.failures + 1
+	if b.state == circuitClosed && b.failures >= b.threshold {
+		b.state, b.openedAt = circuitOpen, time.Now()
+		b.logger.Log(ctx, LevelWarn, "circuit open",
+			Field{"failures", b.failures},
+			Field{"cooldown", b.cooldown},
+			Field{"error", err},
+		)
+	}
+}
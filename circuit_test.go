@@ -0,0 +1,156 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// testDownProvider fails upserts and pings to connect while down
+type testDownProvider struct {
+	down    bool
+	upserts int
+	pings   int
+}
+
+var errTestRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func (p *testDownProvider) Upsert(ctx context.Context, args UpsertArgs) error {
+	p.upserts++
+	if p.down {
+		return errTestRefused
+	}
+
+	return nil
+}
+
+func (p *testDownProvider) Ping(ctx context.Context) error {
+	p.pings++
+	if p.down {
+		return errTestRefused
+	}
+
+	return nil
+}
+
+func (p *testDownProvider) Close() {}
+
+func TestCircuitBreaker(t *testing.T) {
+	provider := &testDownProvider{down: true}
+	Register("test-circuit", func(args ConnArgs) (Provider, error) {
+		return provider, nil
+	})
+
+	gob, err := New(WithDBProvider("test-circuit"), WithDBConnStr("test://"), WithCircuitBreaker(2, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	args := UpsertArgs{
+		Model:          "students",
+		Rows:           testGenStudentRowsMySQL(1),
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}
+
+	// consecutive failures open circuit
+	for idx := 0; idx < 2; idx++ {
+		if err := gob.Upsert(context.Background(), args); !errors.Is(err, errTestRefused) {
+			t.Fatalf("upsert %d got: %v want: %v", idx, err, errTestRefused)
+		}
+	}
+
+	err = gob.Upsert(context.Background(), args)
+	if !errors.Is(err, ErrCircuitOpen) || ErrorClass(err) != ErrorClassConn {
+		t.Fatalf("upsert of open circuit got: %v want: %v", err, ErrCircuitOpen)
+	}
+
+	if provider.upserts != 2 || provider.pings != 0 {
+		t.Fatalf("upserts and pings got: %d, %d want: 2, 0", provider.upserts, provider.pings)
+	}
+
+	// failed probe after cooldown keeps circuit open
+	time.Sleep(60 * time.Millisecond)
+	if err := gob.Upsert(context.Background(), args); !errors.Is(err, ErrCircuitOpen) || provider.pings != 1 {
+		t.Fatalf("upsert of failed probe got: %v, %d pings want: %v, 1 ping", err, provider.pings, ErrCircuitOpen)
+	}
+
+	if err := gob.Upsert(context.Background(), args); !errors.Is(err, ErrCircuitOpen) || provider.pings != 1 {
+		t.Fatalf("upsert before cooldown got: %v, %d pings want: %v, 1 ping", err, provider.pings, ErrCircuitOpen)
+	}
+
+	// successful probe closes circuit
+	provider.down = false
+	time.Sleep(60 * time.Millisecond)
+	if err := gob.Upsert(context.Background(), args); err != nil {
+		t.Fatalf("upsert of closed circuit err: %v", err)
+	}
+
+	if provider.upserts != 3 || provider.pings != 2 {
+		t.Fatalf("upserts and pings got: %d, %d want: 3, 2", provider.upserts, provider.pings)
+	}
+
+	for _, option := range []Option{WithCircuitBreaker(0, time.Second), WithCircuitBreaker(1, 0)} {
+		if _, err := New(WithDBProvider(DBProviderMemory), option); err == nil {
+			t.Fatalf("init gob with invalid circuit breaker; want err")
+		}
+	}
+}
+
+func TestCircuitBreakerRecord(t *testing.T) {
+	b := newCircuitBreaker(2, time.Hour, nopLogger{})
+
+	// failures of database, cancellation, closed Gob and success do not open circuit
+	for _, err := range []error{errTestRefused, errors.New("duplicate key"), errTestRefused, context.Canceled, nil, errTestRefused, ErrConnClosed} {
+		b.record(context.Background(), err)
+	}
+
+	if b.state != circuitClosed {
+		t.Fatalf("state got: %s want: %s", b.state, circuitClosed)
+	}
+
+	b.record(context.Background(), errTestRefused)
+
+	// timeouts of callers and WithTimeouts neither count nor reset failures
+	b.record(context.Background(), ctxError(testDoneCtx(), "batch", errors.New("slow query")))
+	if b.state != circuitClosed || b.failures != 1 {
+		t.Fatalf("state after timeout got: %s, %d failures want: %s, 1 failure", b.state, b.failures, circuitClosed)
+	}
+
+	b.record(context.Background(), &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("i/o timeout")})
+	if b.state != circuitOpen {
+		t.Fatalf("state after timeout of dial got: %s want: %s", b.state, circuitOpen)
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	b := newCircuitBreaker(1, time.Nanosecond, nopLogger{})
+	b.record(context.Background(), errTestRefused)
+
+	// probe runs with own deadline though ctx of caller is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var deadline bool
+	if err := b.allow(ctx, func(ctx context.Context) error {
+		_, deadline = ctx.Deadline()
+		return ctx.Err()
+	}); err != nil || !deadline {
+		t.Fatalf("allow got: %v, deadline %v want: nil, probe with deadline", err, deadline)
+	}
+
+	if b.state != circuitClosed {
+		t.Fatalf("state after probe got: %s want: %s", b.state, circuitClosed)
+	}
+}
+
+// testDoneCtx returns ctx past its deadline
+func testDoneCtx() context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	<-ctx.Done()
+	cancel()
+	return ctx
+}
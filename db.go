@@ -1,8 +1,11 @@
 package gob
 
 import (
-	"context"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/csmadhu/gob/utils"
@@ -41,6 +44,39 @@ func (row Row) Len() int {
 	return len(row)
 }
 
+// keyValue returns value of key as bound by database/sql drivers: pointers and driver.Valuer are resolved,
+// integers are int64, floats float64 and times UTC; values not bound by drivers are kept
+func keyValue(value interface{}) interface{} {
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return value
+	}
+
+	if t, ok := converted.(time.Time); ok {
+		return t.UTC()
+	}
+
+	return converted
+}
+
+// encodeKey returns values of keys of row encoded with their types as bound by drivers, each prefixed by its
+// length so values containing separators do not collide; false if any key is missing or NULL as NULL keys
+// never conflict
+func encodeKey(row Row, keys []string) (string, bool) {
+	var b strings.Builder
+	for _, key := range keys {
+		value := keyValue(row.Value(key))
+		if value == nil {
+			return "", false
+		}
+
+		encoded := fmt.Sprintf("%T:%#v", value, value)
+		fmt.Fprintf(&b, "%d:%s", len(encoded), encoded)
+	}
+
+	return b.String(), true
+}
+
 // ConflictAction specifies alternatives ON CONFLICT
 type ConflictAction string
 
@@ -51,21 +87,77 @@ const (
 	ConflictActionUpdate ConflictAction = "update"
 )
 
+// UnknownColumnPolicy specifies handling of row columns not found in model
+type UnknownColumnPolicy string
+
+const (
+	// UnknownColumnError rejects rows with columns not found in model
+	UnknownColumnError UnknownColumnPolicy = "error"
+	// UnknownColumnDrop removes columns not found in model from rows
+	UnknownColumnDrop UnknownColumnPolicy = "drop"
+	// UnknownColumnAdd adds columns not found in model with type inferred from row values
+	UnknownColumnAdd UnknownColumnPolicy = "add"
+)
+
 // UpsertArgs to upsert rows
 type UpsertArgs struct {
 	ConflictAction                 // ON CONFLICT action
-	Keys           []string        // indicate index column names
+	Keys           []string        // index column names or functions of columns e.g. lower(email) (PostgreSQL, SQLite)
+	Constraint     string          // constraint name ON CONFLICT (PostgreSQL)
+	KeyPredicate   string          // predicate of partial unique index; trusted SQL rendered as is (PostgreSQL, SQLite)
+	PartitionKeys  []string        // partition key columns when creating model; defaults to first key (Cassandra)
 	keySet         utils.StringSet // keys converted to set
+	primaryKey     bool            // keys are the primary key of model
 	Model          string          // table name
 	Rows           []Row           // rows to be upserted
+
+	Load   string                // name of resumable load; committed batches are checkpointed with WithCheckpoint
+	Cursor func(rows int) string // cursor of row source after first rows of Rows are committed e.g. offset of message
+	extra  []Statement           // executed in transaction of batch after rows e.g. checkpoint
 }
 
-type db interface {
-	// upsert rows to model with keys
-	upsert(ctx context.Context, args UpsertArgs) error
+// IsKey reports whether column is one of Keys or a column of a function of Keys e.g. email of lower(email);
+// keys are not updated on conflict
+func (args UpsertArgs) IsKey(column string) bool {
+	if args.keySet != nil && args.keySet.Contains(column) {
+		return true
+	}
+
+	if args.keySet == nil && utils.NewStringSet(args.Keys...).Contains(column) {
+		return true
+	}
+
+	for _, key := range args.Keys {
+		if !strings.Contains(key, "(") {
+			continue
+		}
 
-	// close the resources
-	close()
+		for _, keyColumn := range keyColumns(key) {
+			if keyColumn == column {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// keyFunc matches key of function of columns e.g. lower(email) or coalesce(first, last)
+var keyFunc = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\(\s*([A-Za-z_][A-Za-z0-9_$]*(\s*,\s*[A-Za-z_][A-Za-z0-9_$]*)*)\s*\)$`)
+
+// keyColumns returns columns of key of function of columns; nil for other keys
+func keyColumns(key string) []string {
+	match := keyFunc.FindStringSubmatch(key)
+	if match == nil {
+		return nil
+	}
+
+	columns := strings.Split(match[1], ",")
+	for idx, column := range columns {
+		columns[idx] = strings.TrimSpace(column)
+	}
+
+	return columns
 }
 
 // DBProvider for storage
@@ -78,12 +170,24 @@ const (
 	DBProviderMySQL DBProvider = "mysql"
 	// DBProviderCassandra indicates no-sql database provided by Cassandra
 	DBProviderCassandra DBProvider = "cassandra"
+	// DBProviderSQLite indicates embedded relational database provided by SQLite
+	DBProviderSQLite DBProvider = "sqlite"
+	// DBProviderMSSQL indicates relational database provided by Microsoft SQL Server
+	DBProviderMSSQL DBProvider = "mssql"
+	// DBProviderCockroach indicates distributed relational database provided by CockroachDB
+	DBProviderCockroach DBProvider = "cockroach"
+	// DBProviderYugabyte indicates distributed relational database provided by YugabyteDB
+	DBProviderYugabyte DBProvider = "yugabyte"
+	// DBProviderMariaDB indicates relational database provided by MariaDB
+	DBProviderMariaDB DBProvider = "mariadb"
+	// DBProviderTiDB indicates distributed relational database provided by TiDB
+	DBProviderTiDB DBProvider = "tidb"
+	// DBProviderMemory indicates in-memory store for tests
+	DBProviderMemory DBProvider = "memory"
+	// DBProviderCSV indicates CSV files per model
+	DBProviderCSV DBProvider = "csv"
+	// DBProviderNDJSON indicates newline delimited JSON files per model
+	DBProviderNDJSON DBProvider = "ndjson"
+	// DBProviderParquet indicates Parquet files per model
+	DBProviderParquet DBProvider = "parquet"
 )
-
-type connArgs struct {
-	connStr      string
-	idleConns    int
-	openConns    int
-	connIdleTime time.Duration
-	connLifeTime time.Duration
-}
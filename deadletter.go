@@ -0,0 +1,269 @@
+package gob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RejectedRow of model rejected by validation or database
+type RejectedRow struct {
+	Model string
+	Index int // index of row in UpsertArgs.Rows
+	Row   Row
+	Err   error
+	Time  time.Time
+}
+
+// DeadLetter receives rows rejected by validation or database instead of failing the upsert
+type DeadLetter interface {
+	Write(ctx context.Context, rows []RejectedRow) error
+}
+
+// Result of upsert
+type Result struct {
+	Rows        int // rows of committed batches excluding rejected rows
+	Batches     int // committed batches
+	DeadLetters int // rows written to DeadLetter
+}
+
+// deadLetterLine of NDJSON file
+type deadLetterLine struct {
+	Model string    `json:"model"`
+	Index int       `json:"index"`
+	Row   Row       `json:"row"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// fileDeadLetter appends rejected rows of each model to a NDJSON file in directory
+type fileDeadLetter struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileDeadLetter returns DeadLetter appending rejected rows of each model to <model>.ndjson in dir
+// as objects with model, index, row, error and time
+func NewFileDeadLetter(dir string) (DeadLetter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("gob: create directory %s: %w", dir, err)
+	}
+
+	return &fileDeadLetter{dir: dir}, nil
+}
+
+func (dl *fileDeadLetter) Write(ctx context.Context, rows []RejectedRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	lines := make(map[string][]byte)
+	for _, row := range rows {
+		b, err := json.Marshal(deadLetterLine{Model: row.Model, Index: row.Index, Row: row.Row, Error: row.Err.Error(), Time: row.Time})
+		if err != nil {
+			return fmt.Errorf("gob: encode rejected row %d of model '%s': %w", row.Index, row.Model, err)
+		}
+
+		lines[row.Model] = append(append(lines[row.Model], b...), '\n')
+	}
+
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	for model, b := range lines {
+		name := filepath.Join(dl.dir, model+".ndjson")
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("gob: open dead letter file %s: %w", name, err)
+		}
+
+		if _, err := f.Write(b); err != nil {
+			f.Close()
+			return fmt.Errorf("gob: write dead letter file %s: %w", name, err)
+		}
+
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("gob: close dead letter file %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// tableDeadLetter inserts rejected rows into table of target database
+type tableDeadLetter struct {
+	table    string
+	db       txDB // provider of Gob bound by New
+	timeText bool // created_at is RFC 3339 text if time type of database is unknown
+}
+
+// NewTableDeadLetter returns DeadLetter inserting rejected rows into table of target database created if not exists
+// with columns model, row_index, row_json, error and created_at of time type of database. New fails with
+// ErrUnsupported unless provider upserts statements in transactions like checkpoints of NewTableCheckpointStore
+func NewTableDeadLetter(table string) (DeadLetter, error) {
+	if !checkpointTable.MatchString(table) {
+		return nil, fmt.Errorf("gob: invalid dead letter table: %s", table)
+	}
+
+	return &tableDeadLetter{table: table}, nil
+}
+
+// bind dead letter to provider and create table
+func (dl *tableDeadLetter) bind(ctx context.Context, provider Provider) error {
+	db, ok := provider.(txDB)
+	if !ok {
+		return fmt.Errorf("%w dead letters in table %s", ErrUnsupported, dl.table)
+	}
+
+	timeType := db.typeName(columnType{kind: kindTime})
+	if timeType == "" {
+		timeType, dl.timeText = "VARCHAR(64)", true
+	}
+
+	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (model VARCHAR(255) NOT NULL, row_index BIGINT NOT NULL, "+
+		"row_json TEXT NOT NULL, error TEXT NOT NULL, created_at %s NOT NULL)", dl.table, timeType)
+	if err := db.exec(ctx, createSQL); err != nil {
+		return err
+	}
+
+	dl.db = db
+	return nil
+}
+
+// Write rejected rows in a transaction
+func (dl *tableDeadLetter) Write(ctx context.Context, rows []RejectedRow) error {
+	if dl.db == nil {
+		return fmt.Errorf("gob: dead letter table %s not bound to Gob", dl.table)
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	insertSQL := fmt.Sprintf("INSERT INTO %s (model, row_index, row_json, error, created_at) VALUES (%s, %s, %s, %s, %s)",
+		dl.table, dl.db.placeholder(1), dl.db.placeholder(2), dl.db.placeholder(3), dl.db.placeholder(4), dl.db.placeholder(5))
+
+	statements := make([]Statement, len(rows))
+	for idx, row := range rows {
+		b, err := json.Marshal(row.Row)
+		if err != nil {
+			return fmt.Errorf("gob: encode rejected row %d of model '%s': %w", row.Index, row.Model, err)
+		}
+
+		var createdAt interface{} = row.Time.UTC()
+		if dl.timeText {
+			createdAt = row.Time.UTC().Format(time.RFC3339Nano)
+		}
+
+		statements[idx] = Statement{
+			SQL:  insertSQL,
+			Args: []interface{}{row.Model, int64(row.Index), string(b), row.Err.Error(), createdAt},
+		}
+	}
+
+	if err := dl.db.upsertTx(ctx, UpsertArgs{Model: dl.table}, statements); err != nil {
+		return fmt.Errorf("gob: write dead letters to %s: %w", dl.table, err)
+	}
+
+	return nil
+}
+
+// isolatable reports whether rows of batch failed with err may be upserted one by one to reject failing rows;
+// only errors of values of rows are. Errors of schema, permissions, syntax, connection, context and arguments
+// fail all rows
+func isolatable(err error) bool {
+	return ErrorClass(err) == ErrorClassInvalidRows || pgRowError(err) || mysqlRowError(err) ||
+		mssqlRowError(err) || sqliteRowError(err)
+}
+
+// rejectInvalid replaces rows rejected by validation with empty rows ignored by providers; returns rejected rows
+// or err if not a ValidationError. Rows of caller are not modified
+func rejectInvalid(model string, rows []Row, offset int, err error) ([]Row, []RejectedRow, error) {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, nil, err
+	}
+
+	var (
+		kept     = append([]Row(nil), rows...)
+		rejected = make([]RejectedRow, len(validationErr.Rows))
+		now      = time.Now()
+	)
+
+	for idx, rowErr := range validationErr.Rows {
+		rejected[idx] = RejectedRow{
+			Model: model,
+			Index: rowErr.Index,
+			Row:   rows[rowErr.Index-offset],
+			Err:   &ValidationError{Model: model, Rows: []RowError{rowErr}},
+			Time:  now,
+		}
+		kept[rowErr.Index-offset] = Row{}
+	}
+
+	return kept, rejected, nil
+}
+
+// isolate upserts rows of failed batch one by one; returns rows rejected by database.
+// offset is the index of first row in UpsertArgs.Rows
+func isolate(ctx context.Context, provider Provider, args UpsertArgs, offset int) ([]RejectedRow, error) {
+	var rejected []RejectedRow
+
+	rows := args.Rows
+	args.extra = nil
+	for idx, row := range rows {
+		if row.Len() == 0 {
+			continue // ignore empty row
+		}
+
+		args.Rows = rows[idx : idx+1]
+		if err := provider.Upsert(ctx, args); err != nil {
+			if !isolatable(err) {
+				return nil, err
+			}
+
+			rejected = append(rejected, RejectedRow{Model: args.Model, Index: offset + idx, Row: row, Err: err, Time: time.Now()})
+		}
+	}
+
+	return rejected, nil
+}
+
+// applyRows applies fn to rows of batch starting at index of UpsertArgs.Rows; with dead letter rows rejected by fn
+// are appended to rejected and fn is applied to remaining rows
+func (gob *Gob) applyRows(model string, rows []Row, index int, rejected *[]RejectedRow, fn func(rows []Row) ([]Row, error)) ([]Row, error) {
+	result, err := fn(rows)
+	if err == nil || gob.deadLetter == nil {
+		return result, err
+	}
+
+	kept, invalid, err := rejectInvalid(model, rows, index, err)
+	if err != nil {
+		return nil, err
+	}
+
+	*rejected = append(*rejected, invalid...)
+	return fn(kept)
+}
+
+// writeDeadLetters writes rejected rows to dead letter
+func (gob *Gob) writeDeadLetters(ctx context.Context, rejected []RejectedRow) error {
+	if len(rejected) == 0 {
+		return nil
+	}
+
+	if err := gob.deadLetter.Write(ctx, rejected); err != nil {
+		return err
+	}
+
+	gob.logger.Log(ctx, LevelWarn, "dead letter",
+		Field{"model", rejected[0].Model},
+		Field{"rows", len(rejected)},
+	)
+	return nil
+}
@@ -0,0 +1,254 @@
+package gob
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mssqldb "github.com/denisenkom/go-mssqldb"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+func TestDeadLetterFile(t *testing.T) {
+	dir := t.TempDir()
+	dl, err := NewFileDeadLetter(dir)
+	if err != nil {
+		t.Fatalf("init dead letter; err: %v", err)
+	}
+
+	gob, err := New(WithDBProvider(DBProviderMemory), WithCreateModel(10), WithValidation(true), WithBatchSize(3), WithDeadLetter(dl))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	// create model with columns of rows
+	rows := testGenStudentRowsMySQL(5)
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	rows = testGenStudentRowsMySQL(5)
+	rows[1] = Row{"name": "name-1", "age": "abc"}
+	rows[4] = Row{"name": "name-4", "age": []int{1}}
+
+	result, err := gob.UpsertWithResult(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	})
+	if err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	if want := (Result{Rows: 3, Batches: 2, DeadLetters: 2}); result != want {
+		t.Fatalf("result got: %+v want: %+v", result, want)
+	}
+
+	if got, _ := gob.Provider().(*Memory).Get("students", Row{"name": "name-1"}); got.Value("age") != int64(1) {
+		t.Fatalf("age of rejected row got: %#v want: 1", got.Value("age"))
+	}
+
+	f, err := os.Open(filepath.Join(dir, "students.ndjson"))
+	if err != nil {
+		t.Fatalf("open dead letter file; err: %v", err)
+	}
+	defer f.Close()
+
+	var indexes []int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line deadLetterLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode line %s; err: %v", scanner.Text(), err)
+		}
+
+		if line.Model != "students" || line.Row.Value("name") == nil || line.Error == "" || line.Time.IsZero() {
+			t.Fatalf("line got: %+v want: rejected row of students", line)
+		}
+		indexes = append(indexes, line.Index)
+	}
+
+	if len(indexes) != 2 || indexes[0] != 1 || indexes[1] != 4 {
+		t.Fatalf("indexes of rejected rows got: %v want: [1 4]", indexes)
+	}
+}
+
+func TestDeadLetterTable(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup sqlite; err: %v", err)
+	}
+
+	if _, err := testSQLiteDB.Exec("DROP TABLE IF EXISTS gob_errors"); err != nil {
+		t.Fatalf("drop table; err: %v", err)
+	}
+
+	// NOT NULL constraint of name rejects row 3
+	rows := testGenStudentRowsSQLite(5)
+	rows[3] = Row{"name": nil, "age": 3}
+	args := UpsertArgs{
+		Model:          "students",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}
+
+	t.Run("withoutDeadLetter", func(t *testing.T) {
+		gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		if err := gob.Upsert(context.Background(), args); err == nil {
+			t.Fatalf("upsert NULL name; want err")
+		}
+	})
+
+	dl, err := NewTableDeadLetter("gob_errors")
+	if err != nil {
+		t.Fatalf("init dead letter; err: %v", err)
+	}
+
+	gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr), WithDeadLetter(dl))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	result, err := gob.UpsertWithResult(context.Background(), args)
+	if err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	if want := (Result{Rows: 4, Batches: 1, DeadLetters: 1}); result != want {
+		t.Fatalf("result got: %+v want: %+v", result, want)
+	}
+
+	testVerifyStudentRowsSQLite(t, append(rows[:3:3], rows[4]))
+
+	var (
+		model, rowJSON, errMsg string
+		index                  int
+	)
+	if err := testSQLiteDB.QueryRow("SELECT model, row_index, row_json, error FROM gob_errors").Scan(&model, &index, &rowJSON, &errMsg); err != nil {
+		t.Fatalf("query dead letters; err: %v", err)
+	}
+
+	if model != "students" || index != 3 || rowJSON != `{"age":3,"name":null}` || errMsg == "" {
+		t.Fatalf("dead letter got: %s, %d, %s, %s want: row 3 of students", model, index, rowJSON, errMsg)
+	}
+
+	// errors of schema fail the upsert instead of rejecting every row
+	t.Run("unknownColumn", func(t *testing.T) {
+		args.Rows = []Row{{"name": "name-5", "unknown": 1}}
+		if _, err := gob.UpsertWithResult(context.Background(), args); err == nil {
+			t.Fatalf("upsert unknown column; want err")
+		}
+
+		var count int
+		if err := testSQLiteDB.QueryRow("SELECT COUNT(*) FROM gob_errors").Scan(&count); err != nil || count != 1 {
+			t.Fatalf("dead letters got: %d, %v want: 1", count, err)
+		}
+	})
+}
+
+// testTextDialect is SQLite as dialect of unknown database
+type testTextDialect struct {
+	Dialect
+}
+
+func TestDeadLetterTableTime(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup sqlite; err: %v", err)
+	}
+
+	if _, err := testSQLiteDB.Exec("DROP TABLE IF EXISTS gob_errors"); err != nil {
+		t.Fatalf("drop table; err: %v", err)
+	}
+
+	provider, err := NewSQLProvider(ConnArgs{ConnStr: testSQLiteConnStr}, testTextDialect{DialectSQLite})
+	if err != nil {
+		t.Fatalf("init provider; err: %v", err)
+	}
+	defer provider.Close()
+
+	dl := &tableDeadLetter{table: "gob_errors"}
+	if err := dl.bind(context.Background(), provider); err != nil {
+		t.Fatalf("bind dead letter; err: %v", err)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	if err := dl.Write(context.Background(), []RejectedRow{{Model: "students", Row: Row{"name": "name-0"}, Err: ErrInvalidRows, Time: now}}); err != nil {
+		t.Fatalf("write dead letters; err: %v", err)
+	}
+
+	// time type of unknown database is text
+	var createdAt string
+	if err := testSQLiteDB.QueryRow("SELECT created_at FROM gob_errors").Scan(&createdAt); err != nil {
+		t.Fatalf("query dead letters; err: %v", err)
+	}
+
+	if want := now.Format(time.RFC3339Nano); createdAt != want {
+		t.Fatalf("created_at got: %s want: %s", createdAt, want)
+	}
+}
+
+func TestDeadLetterErrors(t *testing.T) {
+	dl, _ := NewTableDeadLetter("gob_errors")
+	if _, err := New(WithDBProvider(DBProviderMemory), WithDeadLetter(dl)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("init gob with table dead letter of memory err got: %v want: %v", err, ErrUnsupported)
+	}
+
+	if _, err := New(WithDBProvider(DBProviderPg), WithDryRun(&bytes.Buffer{}, false), WithDeadLetter(dl)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("init gob with table dead letter of dry run err got: %v want: %v", err, ErrUnsupported)
+	}
+
+	if _, err := NewTableDeadLetter("gob errors"); err == nil {
+		t.Fatalf("init dead letter with invalid table; want err")
+	}
+
+	if _, err := New(WithDBProvider(DBProviderMemory), WithDeadLetter(nil)); err == nil {
+		t.Fatalf("init gob with nil dead letter; want err")
+	}
+}
+
+func TestIsolatable(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("%w row 1", ErrInvalidRows), true},
+		{&pgconn.PgError{Code: "23505"}, true},             // unique violation
+		{&pgconn.PgError{Code: "22001"}, true},             // string data right truncation
+		{&pgconn.PgError{Code: "42P01"}, false},            // undefined table
+		{&pgconn.PgError{Code: "42501"}, false},            // insufficient privilege
+		{&mysqldriver.MySQLError{Number: 1062}, true},      // duplicate entry
+		{&mysqldriver.MySQLError{Number: 1054}, false},     // unknown column
+		{mssqldb.Error{Number: 2627}, true},                // violation of primary key
+		{mssqldb.Error{Number: 208}, false},                // invalid object name
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, true}, // constraint failed
+		{sqlite3.Error{Code: sqlite3.ErrError}, false},     // no such column
+		{errors.New("syntax error"), false},
+		{context.DeadlineExceeded, false},
+	} {
+		if got := isolatable(test.err); got != test.want {
+			t.Fatalf("isolatable %v got: %v want: %v", test.err, got, test.want)
+		}
+	}
+}
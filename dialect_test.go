@@ -0,0 +1,28 @@
+package gob_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/csmadhu/gob"
+	"github.com/csmadhu/gob/dialecttest"
+)
+
+func TestDialectSQLite(t *testing.T) {
+	dialecttest.Run(t, gob.DialectSQLite, "file:dialecttest?mode=memory&cache=shared")
+}
+
+func TestDialectMySQL(t *testing.T) {
+	connStr := "gob:fY5SGU=t@(localhost:3306)/gob"
+	db, err := sql.Open("mysql", connStr)
+	if err != nil {
+		t.Fatalf("connect to MySQL; err: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Skipf("skip: MySQL unreachable: %v", err)
+	}
+
+	dialecttest.Run(t, gob.DialectMySQL, connStr)
+}
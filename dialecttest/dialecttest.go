@@ -0,0 +1,219 @@
+// Package dialecttest verifies implementations of gob.Dialect against a live database
+package dialecttest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/csmadhu/gob"
+)
+
+// Model upserted by Run; dropped and created on each run
+const Model = "gob_conformance"
+
+// createModelSQL is portable across databases supported by database/sql drivers
+const createModelSQL = "CREATE TABLE " + Model + "(name VARCHAR(64) NOT NULL PRIMARY KEY, age INTEGER, nickname VARCHAR(64))"
+
+// record of model read back from database
+type record struct {
+	age      sql.NullInt64
+	nickname sql.NullString
+}
+
+// Run upserts rows to Model of database at connStr using the generic SQL provider of dialect and verifies contents of Model;
+// every Dialect must pass Run
+func Run(t *testing.T, dialect gob.Dialect, connStr string) {
+	db, err := sql.Open(dialect.DriverName(), connStr)
+	if err != nil {
+		t.Fatalf("connect to %s; err: %v", dialect.DriverName(), err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("DROP TABLE IF EXISTS " + Model); err != nil {
+		t.Fatalf("drop model %s; err: %v", Model, err)
+	}
+
+	if _, err := db.Exec(createModelSQL); err != nil {
+		t.Fatalf("create model %s; err: %v", Model, err)
+	}
+
+	provider, err := gob.NewSQLProvider(gob.ConnArgs{ConnStr: connStr, IdleConns: 1, OpenConns: 1}, dialect)
+	if err != nil {
+		t.Fatalf("init provider; err: %v", err)
+	}
+	defer provider.Close()
+
+	c := &conformance{db: db, provider: provider, dialect: dialect}
+	for _, test := range []struct {
+		name string
+		fn   func(t *testing.T)
+	}{
+		{"insert", c.testInsert},
+		{"conflictActionUpdate", c.testUpdate},
+		{"conflictActionNothing", c.testNothing},
+		{"partialColumns", c.testPartialColumns},
+		{"nulls", c.testNulls},
+		{"duplicateKeys", c.testDuplicateKeys},
+		{"maxArgs", c.testMaxArgs},
+	} {
+		if _, err := db.Exec("DELETE FROM " + Model); err != nil {
+			t.Fatalf("delete rows of model %s; err: %v", Model, err)
+		}
+
+		t.Run(test.name, test.fn)
+	}
+}
+
+type conformance struct {
+	db       *sql.DB
+	provider gob.Provider
+	dialect  gob.Dialect
+}
+
+func (c *conformance) upsert(t *testing.T, action gob.ConflictAction, rows []gob.Row) {
+	t.Helper()
+
+	if err := c.provider.Upsert(context.Background(), gob.UpsertArgs{
+		Model:          Model,
+		Keys:           []string{"name"},
+		ConflictAction: action,
+		Rows:           rows,
+	}); err != nil {
+		t.Fatalf("upsert rows with conflict action %s; err: %v", action, err)
+	}
+}
+
+func (c *conformance) verify(t *testing.T, want map[string]record) {
+	t.Helper()
+
+	rows, err := c.db.Query("SELECT name, age, nickname FROM " + Model)
+	if err != nil {
+		t.Fatalf("read rows of model %s; err: %v", Model, err)
+	}
+	defer rows.Close()
+
+	got := make(map[string]record)
+	for rows.Next() {
+		var (
+			name string
+			r    record
+		)
+
+		if err := rows.Scan(&name, &r.age, &r.nickname); err != nil {
+			t.Fatalf("scan row of model %s; err: %v", Model, err)
+		}
+		got[name] = r
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatalf("read rows of model %s; err: %v", Model, err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows got: %+v want: %+v", got, want)
+	}
+}
+
+func genRows(count, age int) ([]gob.Row, map[string]record) {
+	var (
+		rows []gob.Row
+		want = make(map[string]record)
+	)
+
+	for idx := 0; idx < count; idx++ {
+		name := fmt.Sprintf("name-%d", idx)
+		nickname := fmt.Sprintf("nickname-%d", idx)
+		rows = append(rows, gob.Row{"name": name, "age": age + idx, "nickname": nickname})
+		want[name] = newRecord(age+idx, nickname)
+	}
+
+	return rows, want
+}
+
+func newRecord(age int, nickname string) record {
+	return record{
+		age:      sql.NullInt64{Int64: int64(age), Valid: true},
+		nickname: sql.NullString{String: nickname, Valid: true},
+	}
+}
+
+func (c *conformance) testInsert(t *testing.T) {
+	rows, want := genRows(10, 0)
+	c.upsert(t, gob.ConflictActionUpdate, rows)
+	c.verify(t, want)
+}
+
+func (c *conformance) testUpdate(t *testing.T) {
+	rows, _ := genRows(10, 0)
+	c.upsert(t, gob.ConflictActionUpdate, rows)
+
+	rows, want := genRows(10, 100)
+	c.upsert(t, gob.ConflictActionUpdate, rows)
+	c.verify(t, want)
+}
+
+func (c *conformance) testNothing(t *testing.T) {
+	rows, want := genRows(10, 0)
+	c.upsert(t, gob.ConflictActionNothing, rows)
+
+	rows, _ = genRows(10, 100)
+	c.upsert(t, gob.ConflictActionNothing, rows)
+	c.verify(t, want)
+}
+
+func (c *conformance) testPartialColumns(t *testing.T) {
+	rows, want := genRows(10, 0)
+	c.upsert(t, gob.ConflictActionUpdate, rows)
+
+	// columns absent from rows are left unchanged
+	c.upsert(t, gob.ConflictActionUpdate, []gob.Row{{"name": "name-0", "age": 100}})
+	want["name-0"] = newRecord(100, "nickname-0")
+	c.verify(t, want)
+}
+
+func (c *conformance) testNulls(t *testing.T) {
+	rows, want := genRows(2, 0)
+	c.upsert(t, gob.ConflictActionUpdate, rows)
+
+	c.upsert(t, gob.ConflictActionUpdate, []gob.Row{
+		{"name": "name-0", "age": nil, "nickname": nil},
+		{"name": "name-2", "age": nil, "nickname": nil},
+	})
+	want["name-0"] = record{}
+	want["name-2"] = record{}
+	c.verify(t, want)
+}
+
+func (c *conformance) testDuplicateKeys(t *testing.T) {
+	// last row of key wins on update
+	c.upsert(t, gob.ConflictActionUpdate, []gob.Row{
+		{"name": "name-0", "age": 0, "nickname": "nickname-0"},
+		{"name": "name-0", "age": 1, "nickname": "nickname-1"},
+	})
+
+	// first row of key wins on nothing
+	c.upsert(t, gob.ConflictActionNothing, []gob.Row{
+		{"name": "name-1", "age": 0, "nickname": "nickname-0"},
+		{"name": "name-1", "age": 1, "nickname": "nickname-1"},
+	})
+
+	c.verify(t, map[string]record{
+		"name-0": newRecord(1, "nickname-1"),
+		"name-1": newRecord(0, "nickname-0"),
+	})
+}
+
+func (c *conformance) testMaxArgs(t *testing.T) {
+	// rows split across statements to fit MaxArgs of dialect
+	count := 10
+	if maxArgs := c.dialect.MaxArgs(); maxArgs > 0 {
+		count = maxArgs/3 + 1
+	}
+
+	rows, want := genRows(count, 0)
+	c.upsert(t, gob.ConflictActionUpdate, rows)
+	c.verify(t, want)
+}
@@ -0,0 +1,105 @@
+package gob
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dryRun writes statements of batches to writer instead of executing them
+type dryRun struct {
+	builder Builder
+	redact  bool // write type of arguments instead of values
+
+	mu      sync.Mutex
+	w       io.Writer
+	batches int
+}
+
+func newDryRun(provider DBProvider, w io.Writer, redact bool) (Provider, error) {
+	builder, err := NewBuilder(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dryRun{builder: builder, redact: redact, w: w}, nil
+}
+
+func (db *dryRun) Upsert(ctx context.Context, args UpsertArgs) error {
+	statements, err := db.builder.Build(args)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.batches = db.batches + 1
+	rows := 0
+	for _, row := range args.Rows {
+		if row.Len() > 0 {
+			rows = rows + 1
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- batch %d: %d rows of model %s\n", db.batches, rows, args.Model)
+	for _, statement := range statements {
+		fmt.Fprintf(&b, "%s;\n", strings.TrimSuffix(statement.SQL, ";"))
+		if len(statement.Args) == 0 {
+			continue
+		}
+
+		values := make([]string, len(statement.Args))
+		for idx, arg := range statement.Args {
+			values[idx] = dryRunValue(arg, db.redact)
+		}
+		fmt.Fprintf(&b, "-- args: %s\n", strings.Join(values, ", "))
+	}
+
+	if _, err := io.WriteString(db.w, b.String()); err != nil {
+		return fmt.Errorf("gob: write dry run of model '%s': %w", args.Model, err)
+	}
+
+	return nil
+}
+
+func (db *dryRun) Close() {}
+
+// dryRunValue formats argument as SQL literal or its Go type if redacted
+func dryRunValue(value interface{}, redact bool) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	if redact {
+		return fmt.Sprintf("<%T>", value)
+	}
+
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	switch v := value.(type) {
+	case string:
+		return quote(v)
+	case []byte:
+		return fmt.Sprintf("0x%x", v)
+	case time.Time:
+		return quote(v.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return quote(v.String())
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+
+	if b, err := json.Marshal(value); err == nil {
+		return quote(string(b))
+	}
+
+	return quote(fmt.Sprint(value))
+}
@@ -0,0 +1,154 @@
+package gob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDryRun(t *testing.T) {
+	birthday := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []Row{
+		{"name": "name-0", "age": 0, "birthday": birthday},
+		{"name": "o'name-1", "age": nil},
+		{},
+		{"name": "name-2", "profile": map[string]string{"state": "state-2"}},
+	}
+
+	tests := []struct {
+		name     string
+		provider DBProvider
+		redact   bool
+		want     string
+	}{
+		{
+			name:     "pg",
+			provider: DBProviderPg,
+			want: `-- batch 1: 2 rows of model students
+INSERT INTO students(age,birthday,name) VALUES($1,$2,$3) ON CONFLICT (name) DO UPDATE SET age=$1,birthday=$2;
+-- args: 0, '2000-01-02T03:04:05Z', 'name-0'
+INSERT INTO students(age,name) VALUES($1,$2) ON CONFLICT (name) DO UPDATE SET age=$1;
+-- args: NULL, 'o''name-1'
+-- batch 2: 1 rows of model students
+INSERT INTO students(name,profile) VALUES($1,$2) ON CONFLICT (name) DO UPDATE SET profile=$2;
+-- args: 'name-2', '{"state":"state-2"}'
+`,
+		},
+		{
+			name:     "redact",
+			provider: DBProviderMySQL,
+			redact:   true,
+			want: "-- batch 1: 2 rows of model students\n" +
+				"INSERT INTO students(`age`,`birthday`,`name`) VALUES(?,?,?) ON DUPLICATE KEY UPDATE `age`=VALUES(`age`),`birthday`=VALUES(`birthday`),`name`=VALUES(`name`);\n" +
+				"-- args: <int>, <time.Time>, <string>\n" +
+				"INSERT INTO students(`age`,`name`) VALUES(?,?) ON DUPLICATE KEY UPDATE `age`=VALUES(`age`),`name`=VALUES(`name`);\n" +
+				"-- args: NULL, <string>\n" +
+				"-- batch 2: 1 rows of model students\n" +
+				"INSERT INTO students(`name`,`profile`) VALUES(?,?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`profile`=VALUES(`profile`);\n" +
+				"-- args: <string>, <map[string]string>\n",
+		},
+		{
+			name:     "mssql",
+			provider: DBProviderMSSQL,
+			want: `-- batch 1: 2 rows of model students
+SELECT TOP 0 age,birthday,name INTO #gob_students FROM students UNION ALL SELECT age,birthday,name FROM students WHERE 1=0;
+INSERT INTO #gob_students (age,birthday,name) VALUES (@p1,@p2,@p3);
+-- args: 0, '2000-01-02T03:04:05Z', 'name-0'
+IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'age',N'birthday',N'name')) SET IDENTITY_INSERT students ON;
+MERGE INTO students WITH (HOLDLOCK) AS target USING #gob_students AS source ON (target.name=source.name) WHEN MATCHED THEN UPDATE SET target.age=source.age,target.birthday=source.birthday WHEN NOT MATCHED THEN INSERT (age,birthday,name) VALUES (source.age,source.birthday,source.name);
+IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'age',N'birthday',N'name')) SET IDENTITY_INSERT students OFF;
+DROP TABLE #gob_students;
+SELECT TOP 0 age,name INTO #gob_students FROM students UNION ALL SELECT age,name FROM students WHERE 1=0;
+INSERT INTO #gob_students (age,name) VALUES (@p1,@p2);
+-- args: NULL, 'o''name-1'
+IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'age',N'name')) SET IDENTITY_INSERT students ON;
+MERGE INTO students WITH (HOLDLOCK) AS target USING #gob_students AS source ON (target.name=source.name) WHEN MATCHED THEN UPDATE SET target.age=source.age WHEN NOT MATCHED THEN INSERT (age,name) VALUES (source.age,source.name);
+IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'age',N'name')) SET IDENTITY_INSERT students OFF;
+DROP TABLE #gob_students;
+-- batch 2: 1 rows of model students
+SELECT TOP 0 name,profile INTO #gob_students FROM students UNION ALL SELECT name,profile FROM students WHERE 1=0;
+INSERT INTO #gob_students (name,profile) VALUES (@p1,@p2);
+-- args: 'name-2', '{"state":"state-2"}'
+IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'name',N'profile')) SET IDENTITY_INSERT students ON;
+MERGE INTO students WITH (HOLDLOCK) AS target USING #gob_students AS source ON (target.name=source.name) WHEN MATCHED THEN UPDATE SET target.profile=source.profile WHEN NOT MATCHED THEN INSERT (name,profile) VALUES (source.name,source.profile);
+IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(N'students') AND name IN (N'name',N'profile')) SET IDENTITY_INSERT students OFF;
+DROP TABLE #gob_students;
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			// conn string is not used by dry run
+			gob, err := New(WithDBProvider(test.provider), WithDBConnStr("unreachable://"), WithDryRun(&buf, test.redact), WithBatchSize(3))
+			if err != nil {
+				t.Fatalf("init gob; err: %v", err)
+			}
+			defer gob.Close()
+
+			if err := gob.Upsert(context.Background(), UpsertArgs{
+				Model:          "students",
+				Keys:           []string{"name"},
+				ConflictAction: ConflictActionUpdate,
+				Rows:           rows,
+			}); err != nil {
+				t.Fatalf("upsert rows err: %v", err)
+			}
+
+			if got := buf.String(); got != test.want {
+				t.Fatalf("dry run got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("invalidConflictTarget", func(t *testing.T) {
+		var buf bytes.Buffer
+		gob, err := New(WithDryRun(&buf, false))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Keys:           []string{"name); DROP TABLE students; --"},
+			ConflictAction: ConflictActionUpdate,
+			Rows:           rows,
+		}); !errors.Is(err, ErrInvalidConflictTarget) {
+			t.Fatalf("upsert got: %v want: %v", err, ErrInvalidConflictTarget)
+		}
+	})
+
+	t.Run("withoutKeys", func(t *testing.T) {
+		for _, test := range []struct {
+			provider DBProvider
+			want     error
+		}{
+			{DBProviderMySQL, nil},
+			{DBProviderCassandra, nil},
+			{DBProviderPg, ErrEmptykeys},
+		} {
+			gob, err := New(WithDBProvider(test.provider), WithDBConnStr("unreachable://"), WithDryRun(&bytes.Buffer{}, false))
+			if err != nil {
+				t.Fatalf("init gob; err: %v", err)
+			}
+
+			if err := gob.Upsert(context.Background(), UpsertArgs{
+				Model:          "students",
+				ConflictAction: ConflictActionUpdate,
+				Rows:           rows,
+			}); !errors.Is(err, test.want) {
+				t.Fatalf("upsert without keys to %s got: %v want: %v", test.provider, err, test.want)
+			}
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		if _, err := New(WithDBProvider(DBProviderMemory), WithDryRun(&bytes.Buffer{}, false)); !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("init gob got: %v want: %v", err, ErrUnsupported)
+		}
+	})
+}
@@ -23,4 +23,35 @@ var (
 
 	// ErrEmptyConflictAction when conflict action not specified
 	ErrEmptyConflictAction = errors.New("gob: empty conflict action;")
+
+	// ErrInvalidConflictTarget when keys, constraint or key predicate can not be used ON CONFLICT
+	ErrInvalidConflictTarget = errors.New("gob: invalid conflict target;")
+
+	// ErrModelNotFound when model does not exist in database
+	ErrModelNotFound = errors.New("gob: model not found;")
+
+	// ErrEmptyRows when model is created without rows
+	ErrEmptyRows = errors.New("gob: empty rows;")
+
+	// ErrInvalidRows when rows do not match columns of model
+	ErrInvalidRows = errors.New("gob: invalid rows;")
+
+	// ErrUnsupported when provider does not implement capability required by an option
+	ErrUnsupported = errors.New("gob: unsupported by provider;")
+
+	// ErrEmptyLoad when checkpoint of load is read with empty load
+	ErrEmptyLoad = errors.New("gob: empty load;")
+
+	// ErrNoCheckpointStore when load is upserted or resumed without WithCheckpoint
+	ErrNoCheckpointStore = errors.New("gob: no checkpoint store;")
+
+	// ErrTimeout when batch or statement exceeds timeout of WithTimeouts or deadline of context;
+	// errors of ErrTimeout are also context.DeadlineExceeded
+	ErrTimeout = errors.New("gob: timeout;")
+
+	// ErrCanceled when context of upsert is canceled; errors of ErrCanceled are also context.Canceled
+	ErrCanceled = errors.New("gob: canceled;")
+
+	// ErrCircuitOpen when Gob.Upsert fails fast after consecutive connectivity failures of WithCircuitBreaker
+	ErrCircuitOpen = errors.New("gob: circuit open;")
 )
@@ -0,0 +1,441 @@
+package gob
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fileOpColumn holds conflict action of row in files; upserts are replayed from files by applying
+// conflict action of each row on key columns written first
+const fileOpColumn = "_op"
+
+// fileColumn of file in file order
+type fileColumn struct {
+	name string
+	typ  columnType
+}
+
+// fileEncoder writes rows of a file
+type fileEncoder interface {
+	// write row of batch with conflict action
+	write(op ConflictAction, row Row) error
+	// flush rows of batch to file
+	flush() error
+	// close file; called once after flush
+	close() error
+}
+
+// fileFormat of files written by file sink
+type fileFormat struct {
+	ext string
+	// open returns encoder of file with columns
+	open func(w io.Writer, columns []fileColumn) (fileEncoder, error)
+	// compatible reports whether values of type fit column of file
+	compatible func(column, typ columnType) bool
+}
+
+// fileSink writes batches of rows to files per model in directory with rotation by row count and size
+type fileSink struct {
+	format   fileFormat
+	dir      string
+	maxRows  int64 // rotate file after rows, 0 for no limit
+	maxBytes int64 // rotate file after bytes, 0 for no limit
+
+	mu    sync.Mutex
+	files map[string]*sinkFile // open file per model
+}
+
+// sinkFile open for writing
+type sinkFile struct {
+	file    *os.File
+	buf     *bufio.Writer
+	columns []fileColumn
+	encoder fileEncoder
+	rows    int64
+	bytes   int64
+}
+
+func init() {
+	Register(DBProviderCSV, newFileSinkFactory(fileFormat{ext: "csv", open: newCSVEncoder, compatible: anyCompatible}))
+	Register(DBProviderNDJSON, newFileSinkFactory(fileFormat{ext: "ndjson", open: newNDJSONEncoder, compatible: anyCompatible}))
+}
+
+// newFileSinkFactory returns factory of file sink writing files of format;
+// conn string is directory with optional maxRows and maxBytes e.g. /data/students?maxRows=100000&maxBytes=67108864
+func newFileSinkFactory(format fileFormat) Factory {
+	return func(args ConnArgs) (Provider, error) {
+		u, err := url.Parse(args.ConnStr)
+		if err != nil {
+			return nil, fmt.Errorf("gob: parse connStr: %w", err)
+		}
+
+		sink := &fileSink{format: format, dir: u.Path, files: make(map[string]*sinkFile)}
+		if u.Scheme != "" && u.Scheme != "file" {
+			return nil, fmt.Errorf("gob: invalid connStr scheme %s of %s files", u.Scheme, format.ext)
+		}
+
+		if sink.dir == "" {
+			sink.dir = "."
+		}
+
+		query := u.Query()
+		if sink.maxRows, err = fileLimit(query.Get("maxRows")); err != nil {
+			return nil, fmt.Errorf("gob: parse maxRows: %w", err)
+		}
+
+		if sink.maxBytes, err = fileLimit(query.Get("maxBytes")); err != nil {
+			return nil, fmt.Errorf("gob: parse maxBytes: %w", err)
+		}
+
+		if err := os.MkdirAll(sink.dir, 0755); err != nil {
+			return nil, fmt.Errorf("gob: create directory %s: %w", sink.dir, err)
+		}
+
+		return sink, nil
+	}
+}
+
+func fileLimit(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit < 0 {
+		return 0, fmt.Errorf("negative limit %d", limit)
+	}
+
+	return limit, nil
+}
+
+// Upsert writes rows of batch to file of model; file is rotated before a row exceeding limits
+// and before a batch with columns not in file. Rows written before an error remain in file
+func (sink *fileSink) Upsert(ctx context.Context, args UpsertArgs) error {
+	columns := fileColumns(args)
+	if len(columns) == 1 {
+		return nil // zero rows
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	f := sink.files[args.Model]
+	if f != nil && !f.fits(columns, sink.format) {
+		if err := sink.rotate(args.Model); err != nil {
+			return err
+		}
+		f = nil
+	}
+
+	for _, row := range args.Rows {
+		if row.Len() == 0 {
+			continue // ignore empty row
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if f != nil && f.full(sink.maxRows, sink.maxBytes) {
+			if err := sink.rotate(args.Model); err != nil {
+				return err
+			}
+			f = nil
+		}
+
+		if f == nil {
+			var err error
+			if f, err = sink.create(args.Model, columns); err != nil {
+				return err
+			}
+		}
+
+		if err := f.encoder.write(args.ConflictAction, row); err != nil {
+			return fmt.Errorf("gob: write row to %s: %w", f.file.Name(), err)
+		}
+		f.rows = f.rows + 1
+	}
+
+	if f == nil {
+		return nil
+	}
+
+	if err := f.flush(); err != nil {
+		return fmt.Errorf("gob: flush rows to %s: %w", f.file.Name(), err)
+	}
+
+	return nil
+}
+
+// Close files
+func (sink *fileSink) Close() {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	for model := range sink.files {
+		sink.rotate(model)
+	}
+}
+
+// create next file of model with columns; files of previous runs are not overwritten
+func (sink *fileSink) create(model string, columns []fileColumn) (*sinkFile, error) {
+	var (
+		file *os.File
+		err  error
+	)
+
+	for seq := 0; ; seq++ {
+		name := filepath.Join(sink.dir, fmt.Sprintf("%s-%06d.%s", model, seq, sink.format.ext))
+		if file, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); err == nil {
+			break
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("gob: create file %s: %w", name, err)
+		}
+	}
+
+	f := &sinkFile{file: file, buf: bufio.NewWriter(file), columns: columns}
+
+	if f.encoder, err = sink.format.open(f, columns); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("gob: open %s encoder of %s: %w", sink.format.ext, file.Name(), err)
+	}
+
+	sink.files[model] = f
+	return f, nil
+}
+
+// rotate closes file of model
+func (sink *fileSink) rotate(model string) error {
+	f := sink.files[model]
+	delete(sink.files, model)
+
+	if err := f.encoder.flush(); err != nil {
+		f.file.Close()
+		return fmt.Errorf("gob: flush %s: %w", f.file.Name(), err)
+	}
+
+	if err := f.encoder.close(); err != nil {
+		f.file.Close()
+		return fmt.Errorf("gob: close %s: %w", f.file.Name(), err)
+	}
+
+	if err := f.buf.Flush(); err != nil {
+		f.file.Close()
+		return fmt.Errorf("gob: flush %s: %w", f.file.Name(), err)
+	}
+
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("gob: close %s: %w", f.file.Name(), err)
+	}
+
+	return nil
+}
+
+// Write counts bytes written to file
+func (f *sinkFile) Write(p []byte) (int, error) {
+	n, err := f.buf.Write(p)
+	f.bytes = f.bytes + int64(n)
+	return n, err
+}
+
+func (f *sinkFile) flush() error {
+	if err := f.encoder.flush(); err != nil {
+		return err
+	}
+
+	return f.buf.Flush()
+}
+
+// fits reports whether rows with columns may be written to file
+func (f *sinkFile) fits(columns []fileColumn, format fileFormat) bool {
+	types := make(map[string]columnType, len(f.columns))
+	for _, column := range f.columns {
+		types[column.name] = column.typ
+	}
+
+	for _, column := range columns {
+		typ, ok := types[column.name]
+		if !ok || !format.compatible(typ, column.typ) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *sinkFile) full(maxRows, maxBytes int64) bool {
+	return (maxRows > 0 && f.rows >= maxRows) || (maxBytes > 0 && f.bytes >= maxBytes)
+}
+
+// fileColumns returns _op, keys and other columns of rows in sorted order with types inferred from rows
+func fileColumns(args UpsertArgs) []fileColumn {
+	var (
+		columns = []fileColumn{{name: fileOpColumn, typ: columnType{kind: kindString}}}
+		types   = make(map[string]columnType)
+		others  []string
+		seen    = make(map[string]bool)
+	)
+
+	for _, key := range args.Keys {
+		seen[key] = true
+	}
+
+	for _, row := range args.Rows {
+		for _, column := range row.Columns() {
+			types[column] = mergeType(types[column], inferType(row.Value(column)))
+			if !seen[column] {
+				seen[column] = true
+				others = append(others, column)
+			}
+		}
+	}
+
+	if len(types) == 0 {
+		return columns
+	}
+
+	sort.Strings(others)
+	for _, key := range args.Keys {
+		columns = append(columns, fileColumn{name: key, typ: types[key]})
+	}
+
+	for _, column := range others {
+		columns = append(columns, fileColumn{name: column, typ: types[column]})
+	}
+
+	return columns
+}
+
+func anyCompatible(column, typ columnType) bool {
+	return true
+}
+
+// fileString formats value of CSV cell; empty for nil
+func fileString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case *time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	}
+
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+// csvEncoder writes header of columns followed by a record per row
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []fileColumn
+}
+
+func newCSVEncoder(w io.Writer, columns []fileColumn) (fileEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w), columns: columns}
+
+	header := make([]string, len(columns))
+	for idx, column := range columns {
+		header[idx] = column.name
+	}
+
+	return e, e.w.Write(header)
+}
+
+func (e *csvEncoder) write(op ConflictAction, row Row) error {
+	record := []string{string(op)}
+	for _, column := range e.columns[1:] {
+		value, err := fileString(row.Value(column.name))
+		if err != nil {
+			return fmt.Errorf("format column %s: %w", column.name, err)
+		}
+		record = append(record, value)
+	}
+
+	if err := e.w.Write(record); err != nil {
+		return err
+	}
+
+	// count bytes of record towards size of file
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) close() error {
+	return nil
+}
+
+// ndjsonEncoder writes an object per line with columns of row in file order; absent columns are omitted
+type ndjsonEncoder struct {
+	w       io.Writer
+	columns []fileColumn
+}
+
+func newNDJSONEncoder(w io.Writer, columns []fileColumn) (fileEncoder, error) {
+	return &ndjsonEncoder{w: w, columns: columns}, nil
+}
+
+func (e *ndjsonEncoder) write(op ConflictAction, row Row) error {
+	line := []byte(fmt.Sprintf(`{"%s":%q`, fileOpColumn, op))
+	for _, column := range e.columns[1:] {
+		value, ok := row[column.name]
+		if !ok {
+			continue
+		}
+
+		name, _ := json.Marshal(column.name)
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("marshal column %s: %w", column.name, err)
+		}
+
+		line = append(line, ',')
+		line = append(line, name...)
+		line = append(line, ':')
+		line = append(line, b...)
+	}
+
+	line = append(line, '}', '\n')
+	_, err := e.w.Write(line)
+	return err
+}
+
+func (e *ndjsonEncoder) flush() error {
+	return nil
+}
+
+func (e *ndjsonEncoder) close() error {
+	return nil
+}
@@ -0,0 +1,252 @@
+package gob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func testFileSink(t *testing.T, provider DBProvider, query string) (Provider, string) {
+	dir, err := ioutil.TempDir("", "gob")
+	if err != nil {
+		t.Fatalf("create temp dir err: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	factory, _ := lookupFactory(provider)
+	sink, err := factory(ConnArgs{ConnStr: dir + query})
+	if err != nil {
+		t.Fatalf("init %s sink err: %v", provider, err)
+	}
+
+	return sink, dir
+}
+
+func testReadFile(t *testing.T, name string) string {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("read file %s err: %v", name, err)
+	}
+
+	return string(b)
+}
+
+func testFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatalf("list files err: %v", err)
+	}
+
+	for idx := range files {
+		files[idx] = filepath.Base(files[idx])
+	}
+
+	return files
+}
+
+func TestFileSinkCSV(t *testing.T) {
+	sink, dir := testFileSink(t, DBProviderCSV, "?maxRows=2")
+
+	birthday := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := sink.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+		Rows: []Row{
+			{"name": "name-0", "age": 0, "birthday": birthday, "subjects": []string{"english"}},
+			{},
+			{"name": "name-1", "age": nil},
+			{"name": "name-2", "age": 2},
+		},
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	// columns not in file rotate file
+	if err := sink.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionNothing,
+		Rows:           []Row{{"name": "name-3", "nickname": "nick, name"}},
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+	sink.Close()
+
+	want := map[string]string{
+		"students-000000.csv": "_op,name,age,birthday,subjects\nupdate,name-0,0,2000-01-02T03:04:05Z,\"[\"\"english\"\"]\"\nupdate,name-1,,,\n",
+		"students-000001.csv": "_op,name,age,birthday,subjects\nupdate,name-2,2,,\n",
+		"students-000002.csv": "_op,name,nickname\nnothing,name-3,\"nick, name\"\n",
+	}
+
+	if got := testFiles(t, dir); len(got) != len(want) {
+		t.Fatalf("files got: %v want: %d files", got, len(want))
+	}
+
+	for name, content := range want {
+		if got := testReadFile(t, filepath.Join(dir, name)); got != content {
+			t.Fatalf("file %s got: %q want: %q", name, got, content)
+		}
+	}
+
+	t.Run("existingFiles", func(t *testing.T) {
+		factory, _ := lookupFactory(DBProviderCSV)
+		sink, err := factory(ConnArgs{ConnStr: dir})
+		if err != nil {
+			t.Fatalf("init csv sink err: %v", err)
+		}
+
+		if err := sink.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+			Rows:           []Row{{"name": "name-4"}},
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+		sink.Close()
+
+		if got := testReadFile(t, filepath.Join(dir, "students-000003.csv")); got != "_op,name\nupdate,name-4\n" {
+			t.Fatalf("file got: %q", got)
+		}
+	})
+}
+
+func TestFileSinkNDJSON(t *testing.T) {
+	sink, dir := testFileSink(t, DBProviderNDJSON, "?maxBytes=1")
+
+	for _, name := range []string{"name-0", "name-1"} {
+		if err := sink.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+			Rows:           []Row{{"name": name, "age": 1, "profile": map[string]string{"state": "state-0"}}, {"name": name, "nickname": nil}},
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+	}
+	sink.Close()
+
+	want := map[string]string{
+		"students-000000.ndjson": `{"_op":"update","name":"name-0","age":1,"profile":{"state":"state-0"}}` + "\n",
+		"students-000001.ndjson": `{"_op":"update","name":"name-0","nickname":null}` + "\n",
+		"students-000002.ndjson": `{"_op":"update","name":"name-1","age":1,"profile":{"state":"state-0"}}` + "\n",
+		"students-000003.ndjson": `{"_op":"update","name":"name-1","nickname":null}` + "\n",
+	}
+
+	if got := testFiles(t, dir); len(got) != len(want) {
+		t.Fatalf("files got: %v want: %d files", got, len(want))
+	}
+
+	for name, content := range want {
+		if got := testReadFile(t, filepath.Join(dir, name)); got != content {
+			t.Fatalf("file %s got: %q want: %q", name, got, content)
+		}
+	}
+}
+
+func TestFileSinkParquet(t *testing.T) {
+	sink, dir := testFileSink(t, DBProviderParquet, "")
+
+	birthday := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, rows := range [][]Row{
+		{{"name": "name-0", "age": 0, "score": 1.5, "active": true, "birthday": birthday}, {"name": "name-1", "age": nil}},
+		{{"name": "name-2", "age": "2", "subjects": []string{"english"}}},
+	} {
+		if err := sink.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+			Rows:           rows,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+	}
+	sink.Close()
+
+	// string age of second batch is not compatible with int column of first file
+	if got, want := testFiles(t, dir), []string{"students-000000.parquet", "students-000001.parquet"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("files got: %v want: %v", got, want)
+	}
+
+	file, err := local.NewLocalFileReader(filepath.Join(dir, "students-000000.parquet"))
+	if err != nil {
+		t.Fatalf("open parquet file err: %v", err)
+	}
+	defer file.Close()
+
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatalf("read parquet file err: %v", err)
+	}
+	defer pr.ReadStop()
+
+	if got := pr.GetNumRows(); got != 2 {
+		t.Fatalf("rows got: %d want: 2", got)
+	}
+
+	want := [][]interface{}{
+		{"update", "update"},
+		{"name-0", "name-1"},
+		{true, nil},
+		{int64(0), nil},
+		{birthday.UnixNano() / int64(time.Millisecond), nil},
+		{1.5, nil},
+	}
+
+	var names []string
+	for _, info := range pr.SchemaHandler.Infos[1:] {
+		names = append(names, info.ExName)
+	}
+
+	if got := strings.Join(names, ","); got != "_op,name,active,age,birthday,score" {
+		t.Fatalf("columns got: %s", got)
+	}
+
+	for idx, values := range want {
+		got, _, _, err := pr.ReadColumnByIndex(int64(idx), 2)
+		if err != nil {
+			t.Fatalf("read column %d err: %v", idx, err)
+		}
+
+		if !reflect.DeepEqual(got, values) {
+			t.Fatalf("column %s got: %#v want: %#v", names[idx], got, values)
+		}
+	}
+}
+
+func TestFileSinkWithoutKeys(t *testing.T) {
+	for _, provider := range []DBProvider{DBProviderCSV, DBProviderNDJSON} {
+		dir, err := ioutil.TempDir("", "gob")
+		if err != nil {
+			t.Fatalf("create temp dir err: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		gob, err := New(WithDBProvider(provider), WithDBConnStr(dir))
+		if err != nil {
+			t.Fatalf("init gob with %s sink; err: %v", provider, err)
+		}
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			ConflictAction: ConflictActionUpdate,
+			Rows:           []Row{{"name": "name-0", "age": 1}},
+		}); err != nil {
+			t.Fatalf("upsert rows without keys to %s sink err: %v", provider, err)
+		}
+		gob.Close()
+
+		if files := testFiles(t, dir); len(files) != 1 {
+			t.Fatalf("files of %s sink got: %v want: 1 file", provider, files)
+		}
+	}
+}
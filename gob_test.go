@@ -12,6 +12,7 @@ import (
 
 func TestNewGob(t *testing.T) {
 	t.Run("defaultGob", func(t *testing.T) {
+		testSetup(t, setupPgDB)
 		got, err := New()
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -31,6 +32,7 @@ func TestNewGob(t *testing.T) {
 	})
 
 	t.Run("customizedGob", func(t *testing.T) {
+		testSetup(t, setupPgDB)
 		want := &Gob{
 			batchSize:    10,
 			dbProvider:   DBProviderPg,
@@ -79,6 +81,7 @@ func TestNewGob(t *testing.T) {
 	})
 
 	t.Run("mysqlProvider", func(t *testing.T) {
+		testSetup(t, setupMySQLDB)
 		if _, err := New(WithDBProvider(DBProviderMySQL),
 			WithDBConnStr(testMySQLConnStr)); err != nil {
 			t.Fatalf("init gob; err: %v", err)
@@ -91,6 +94,7 @@ func TestNewGob(t *testing.T) {
 	})
 
 	t.Run("cassandraProvider", func(t *testing.T) {
+		testSetup(t, setupCassyDB)
 		if _, err := New(WithDBProvider(DBProviderCassandra),
 			WithDBConnStr(testCassyConnString)); err != nil {
 			t.Fatalf("init gob; err: %v", err)
@@ -102,7 +106,20 @@ func TestNewGob(t *testing.T) {
 		}
 	})
 
+	t.Run("sqliteProvider", func(t *testing.T) {
+		if _, err := New(WithDBProvider(DBProviderSQLite),
+			WithDBConnStr(testSQLiteConnStr)); err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+
+		if _, err := New(WithDBProvider(DBProviderSQLite),
+			WithDBConnStr("file:/nonexistent/gob.db?mode=ro")); err == nil {
+			t.Fatalf("init gob; want err")
+		}
+	})
+
 	t.Run("closedGob", func(t *testing.T) {
+		testSetup(t, setupPgDB)
 		gob, err := New()
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -141,13 +158,14 @@ func testVerifyGob(t *testing.T, got, want *Gob) {
 		t.Fatalf("connLifeTime got: %v want: %v", got.connLifeTime, want.connLifeTime)
 	}
 
-	if got.db == nil {
+	if got.provider == nil {
 		t.Fatalf("nil db handler")
 	}
 }
 
 func TestGobUpsert(t *testing.T) {
 	t.Run("closedGob", func(t *testing.T) {
+		testSetup(t, setupPgDB)
 		gob, err := New()
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -160,6 +178,7 @@ func TestGobUpsert(t *testing.T) {
 	})
 
 	t.Run("emptyModel", func(t *testing.T) {
+		testSetup(t, setupPgDB)
 		gob, err := New()
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -171,6 +190,7 @@ func TestGobUpsert(t *testing.T) {
 	})
 
 	t.Run("emptyRow", func(t *testing.T) {
+		testSetup(t, setupPgDB)
 		gob, err := New()
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -182,7 +202,7 @@ func TestGobUpsert(t *testing.T) {
 	})
 
 	t.Run("emptyConflictAction", func(t *testing.T) {
-		setupPgDB()
+		testSetup(t, setupPgDB)
 		gob, err := New(WithBatchSize(10))
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -199,7 +219,7 @@ func TestGobUpsert(t *testing.T) {
 	})
 
 	t.Run("rowCountLessThanBatchsize", func(t *testing.T) {
-		setupPgDB()
+		testSetup(t, setupPgDB)
 		gob, err := New(WithBatchSize(10))
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -219,7 +239,7 @@ func TestGobUpsert(t *testing.T) {
 	})
 
 	t.Run("rowCountEqToBatchsize", func(t *testing.T) {
-		setupPgDB()
+		testSetup(t, setupPgDB)
 		gob, err := New(WithBatchSize(10))
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -239,7 +259,7 @@ func TestGobUpsert(t *testing.T) {
 	})
 
 	t.Run("rowCountGtThanBatchsize", func(t *testing.T) {
-		setupPgDB()
+		testSetup(t, setupPgDB)
 		gob, err := New(WithBatchSize(1001))
 		if err != nil {
 			t.Fatalf("init default gob; err: %v", err)
@@ -257,6 +277,204 @@ func TestGobUpsert(t *testing.T) {
 
 		testVerifyStudentRowsPg(t, rows)
 	})
+
+	t.Run("sqliteProvider", func(t *testing.T) {
+		if err := setupSQLiteDB(); err != nil {
+			t.Fatalf("setup SQLite err: %v", err)
+		}
+
+		gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr), WithBatchSize(10))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		rows := testGenStudentRowsSQLite(25)
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           rows,
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		testVerifyStudentRowsSQLite(t, rows)
+	})
+
+	t.Run("primaryKeyAsKeys", func(t *testing.T) {
+		testSetup(t, setupPgDB)
+		gob, err := New(WithBatchSize(10))
+		if err != nil {
+			t.Fatalf("init default gob; err: %v", err)
+		}
+
+		rows := testGenStudentRowsPg(10)
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           rows,
+			ConflictAction: ConflictActionNothing,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		testVerifyStudentRowsPg(t, rows)
+	})
+}
+
+func TestGobUpsertValidation(t *testing.T) {
+	testSetup(t, setupPgDB)
+	gob, err := New(WithValidation(true))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	t.Run("invalidRows", func(t *testing.T) {
+		rows := testGenStudentRowsPg(2)
+		rows[1].Add("grade", 3)
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           rows,
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); !errors.Is(err, ErrInvalidRows) {
+			t.Fatalf("error got: %v want: %v", err, ErrInvalidRows)
+		}
+	})
+
+	t.Run("coercedRows", func(t *testing.T) {
+		rows := testGenStudentRowsPg(10)
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           rows,
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		testVerifyStudentRowsPg(t, rows)
+	})
+}
+
+func TestGobUpsertUnknownColumns(t *testing.T) {
+	testSetup(t, setupPgDB)
+	gob, err := New(WithUnknownColumnPolicy("students", UnknownColumnAdd))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	rows := testGenStudentRowsPg(10)
+	for _, row := range rows {
+		row.Add("grade", 3)
+	}
+
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	metadata, err := gob.Metadata(context.Background(), "students")
+	if err != nil {
+		t.Fatalf("metadata err: %v", err)
+	}
+
+	if _, ok := metadata.columnSet()["grade"]; !ok {
+		t.Fatalf("column grade not added to model")
+	}
+}
+
+func TestGobUpsertCreateModel(t *testing.T) {
+	testSetup(t, setupPgDB)
+	gob, err := New(WithCreateModel(10))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	if _, err := testPgDB.Exec(context.Background(), "DROP TABLE IF EXISTS teachers"); err != nil {
+		t.Fatalf("drop table err: %v", err)
+	}
+
+	rows := testGenStudentRowsPg(10)
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "teachers",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	metadata, err := gob.Metadata(context.Background(), "teachers")
+	if err != nil {
+		t.Fatalf("metadata err: %v", err)
+	}
+
+	if want := []string{"name"}; !reflect.DeepEqual(metadata.PrimaryKey, want) {
+		t.Fatalf("primaryKey got: %v want: %v", metadata.PrimaryKey, want)
+	}
+}
+
+func TestGobMetadata(t *testing.T) {
+	testSetup(t, setupPgDB)
+	gob, err := New()
+	if err != nil {
+		t.Fatalf("init default gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	t.Run("emptyModel", func(t *testing.T) {
+		if _, err := gob.Metadata(context.Background(), ""); !errors.Is(err, ErrEmptyModel) {
+			t.Fatalf("error got: %v want: %v", err, ErrEmptyModel)
+		}
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		metadata, err := gob.Metadata(context.Background(), "students")
+		if err != nil {
+			t.Fatalf("metadata err: %v", err)
+		}
+
+		if !reflect.DeepEqual(metadata.PrimaryKey, []string{"id"}) {
+			t.Fatalf("primaryKey got: %v want: %v", metadata.PrimaryKey, []string{"id"})
+		}
+
+		cached, err := gob.Metadata(context.Background(), "students")
+		if err != nil {
+			t.Fatalf("metadata err: %v", err)
+		}
+
+		if cached != metadata {
+			t.Fatalf("metadata not cached")
+		}
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		if _, err := testPgDB.Exec(context.Background(), "ALTER TABLE students ADD CONSTRAINT students_age_key UNIQUE (age)"); err != nil {
+			t.Fatalf("alter table err: %v", err)
+		}
+
+		if err := gob.RefreshMetadata(context.Background(), "students"); err != nil {
+			t.Fatalf("refresh metadata err: %v", err)
+		}
+
+		metadata, err := gob.Metadata(context.Background(), "students")
+		if err != nil {
+			t.Fatalf("metadata err: %v", err)
+		}
+
+		want := [][]string{{"age"}, {"name"}}
+		if !reflect.DeepEqual(metadata.UniqueKeys, want) {
+			t.Fatalf("uniqueKeys got: %v want: %v", metadata.UniqueKeys, want)
+		}
+	})
 }
 
 type student struct {
@@ -274,9 +492,9 @@ type studentProfile struct {
 	ZipCode int    `json:"zipcode"`
 }
 
-func testUpsertDB(t *testing.T, dbConn db, genFn func(int) []Row, verifyFn func(t *testing.T, rows []Row)) {
+func testUpsertDB(t *testing.T, dbConn Provider, genFn func(int) []Row, verifyFn func(t *testing.T, rows []Row)) {
 	t.Run("zeroRows", func(t *testing.T) {
-		if err := dbConn.upsert(context.Background(), UpsertArgs{
+		if err := dbConn.Upsert(context.Background(), UpsertArgs{
 			Model:  "students",
 			keySet: utils.NewStringSet("name"),
 			Keys:   []string{"name"},
@@ -287,7 +505,7 @@ func testUpsertDB(t *testing.T, dbConn db, genFn func(int) []Row, verifyFn func(
 
 	t.Run("conflictActionNothing", func(t *testing.T) {
 		rows := genFn(10)
-		if err := dbConn.upsert(context.Background(), UpsertArgs{
+		if err := dbConn.Upsert(context.Background(), UpsertArgs{
 			ConflictAction: ConflictActionNothing,
 			Model:          "students",
 			keySet:         utils.NewStringSet("name"),
@@ -302,7 +520,7 @@ func testUpsertDB(t *testing.T, dbConn db, genFn func(int) []Row, verifyFn func(
 
 	t.Run("conflictActionUpdate", func(t *testing.T) {
 		rows := genFn(15)
-		if err := dbConn.upsert(context.Background(), UpsertArgs{
+		if err := dbConn.Upsert(context.Background(), UpsertArgs{
 			ConflictAction: ConflictActionUpdate,
 			Model:          "students",
 			keySet:         utils.NewStringSet("name"),
@@ -0,0 +1,56 @@
+package gob
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mssqldriver "github.com/csmadhu/gob/internal/driver/mssql"
+	parquetdriver "github.com/csmadhu/gob/internal/driver/parquet"
+	sqlitedriver "github.com/csmadhu/gob/internal/driver/sqlite"
+	"github.com/csmadhu/gob/internal/hooks"
+)
+
+// providers of subpackages sqlite, mssql and parquet are registered as by their init; tests of gob can not import
+// them as they import gob
+func init() {
+	hooks.RegisterSQLite(sqlitedriver.RowError)
+	hooks.RegisterMSSQL(mssqldriver.CopyIn, mssqldriver.RowError)
+	hooks.RegisterParquet(parquetdriver.NewWriter)
+}
+
+// errTestUnreachable of database a test depends on; such tests are skipped
+var errTestUnreachable = errors.New("database unreachable")
+
+// testSetup runs setup of database of test; skips test if database is unreachable
+func testSetup(t *testing.T, setup func() error) {
+	t.Helper()
+
+	err := setup()
+	if errors.Is(err, errTestUnreachable) {
+		t.Skipf("skip: %v", err)
+	}
+
+	if err != nil {
+		t.Fatalf("setup err: %v", err)
+	}
+}
+
+// testGenStudentRowsMySQL of students with profile and subjects as JSON text; shared by tests of all providers
+func testGenStudentRowsMySQL(count int) (rows []Row) {
+	subjects := `["english", "calculus"]`
+	profile := `{"state": "state-%d", "street": "street-%d", "zipcode": %d}`
+	for i := 0; i < count; i++ {
+		row := NewRow()
+		row.Add("name", fmt.Sprintf("name-%d", i))
+		row.Add("age", i)
+		row.Add("profile", fmt.Sprintf(profile, i, i, i))
+		row.Add("subjects", subjects)
+		row.Add("birthday", time.Now())
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
@@ -0,0 +1,33 @@
+// Package mssql links the driver github.com/denisenkom/go-mssqldb to the provider of SQL Server
+package mssql
+
+import (
+	"errors"
+
+	mssqldb "github.com/denisenkom/go-mssqldb"
+)
+
+// CopyIn returns statement bulk copying columns to table; NULL values of rows are kept instead of column defaults
+func CopyIn(table string, columns ...string) string {
+	return mssqldb.CopyIn(table, mssqldb.BulkOptions{KeepNulls: true}, columns...)
+}
+
+// rowErrors are numbers of errors caused by values of a row e.g. 2627 violation of primary key
+var rowErrors = map[int32]bool{
+	220:  true, // arithmetic overflow
+	245:  true, // conversion failed
+	515:  true, // cannot insert NULL
+	547:  true, // conflict with foreign key or check constraint
+	2601: true, // duplicate key of unique index
+	2627: true, // violation of primary key or unique constraint
+	2628: true, // string or binary data truncated
+	8114: true, // error converting data type
+	8115: true, // arithmetic overflow converting
+	8152: true, // string or binary data truncated
+}
+
+// RowError reports whether err is caused by values of a row
+func RowError(err error) bool {
+	var mssqlErr mssqldb.Error
+	return errors.As(err, &mssqlErr) && rowErrors[mssqlErr.Number]
+}
@@ -0,0 +1,14 @@
+// Package sqlite links the cgo driver github.com/mattn/go-sqlite3 to the provider of SQLite
+package sqlite
+
+import (
+	"errors"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// RowError reports whether err is caused by values of a row: constraint violations and datatype mismatches
+func RowError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrConstraint || sqliteErr.Code == sqlite3.ErrMismatch)
+}
@@ -0,0 +1,24 @@
+// Package hooks links drivers imported by subpackages of gob to providers of gob; providers requiring cgo or large
+// dependencies are registered only by programs importing their subpackage
+package hooks
+
+import "io"
+
+// ParquetWriter writes records of parquet file e.g. CSVWriter of parquet-go
+type ParquetWriter interface {
+	Write(record interface{}) error
+	Flush(flag bool) error
+	WriteStop() error
+}
+
+var (
+	// RegisterSQLite registers provider of SQLite; rowError reports whether err of driver is caused by values of a row
+	RegisterSQLite func(rowError func(err error) bool)
+
+	// RegisterMSSQL registers provider of SQL Server; copyIn returns statement bulk copying columns to table keeping
+	// NULL values, rowError reports whether err of driver is caused by values of a row
+	RegisterMSSQL func(copyIn func(table string, columns ...string) string, rowError func(err error) bool)
+
+	// RegisterParquet registers provider of parquet files; newWriter returns writer of file of schema md to w
+	RegisterParquet func(newWriter func(md []string, w io.Writer) (ParquetWriter, error))
+)
@@ -0,0 +1,99 @@
+package gob
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Level of log event; values match levels of log/slog
+type Level int
+
+const (
+	// LevelDebug of start and commit of batches
+	LevelDebug Level = -4
+
+	// LevelInfo of upserts of all rows
+	LevelInfo Level = 0
+
+	// LevelWarn of retries of transactions and slow statements
+	LevelWarn Level = 4
+
+	// LevelError of rollback of batches
+	LevelError Level = 8
+)
+
+// String of level in upper case e.g. WARN; levels between constants are named after the lower one
+func (l Level) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	}
+
+	return "ERROR"
+}
+
+// Field of log event
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives structured events of upserts:
+//
+//	debug: batch start and batch commit
+//	info:  upsert of all rows
+//	warn:  retry of transaction and slow statement
+//	error: batch rollback
+type Logger interface {
+	Log(ctx context.Context, level Level, msg string, fields ...Field)
+}
+
+// LoggerFunc adapts function to Logger
+type LoggerFunc func(ctx context.Context, level Level, msg string, fields ...Field)
+
+// Log calls f(ctx, level, msg, fields...)
+func (f LoggerFunc) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	f(ctx, level, msg, fields...)
+}
+
+// nopLogger discards events; default Logger of Gob
+type nopLogger struct{}
+
+func (nopLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {}
+
+// stdLogger writes events at or above level to log.Logger
+type stdLogger struct {
+	logger *log.Logger
+	level  Level
+}
+
+// NewStdLogger returns Logger writing events at or above level to logger as
+// "gob: LEVEL msg key=value ..."; writes to stderr if logger is nil
+func NewStdLogger(logger *log.Logger, level Level) Logger {
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	return &stdLogger{logger: logger, level: level}
+}
+
+func (l *stdLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "gob: %s %s", level, msg)
+	for _, field := range fields {
+		fmt.Fprintf(&b, " %s=%v", field.Key, field.Value)
+	}
+
+	l.logger.Print(b.String())
+}
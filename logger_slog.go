@@ -0,0 +1,36 @@
+//go:build go1.21
+// +build go1.21
+
+package gob
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger writes events to slog.Logger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns Logger writing events to logger with fields as attributes; default logger of slog if nil
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	if !l.logger.Enabled(ctx, slog.Level(level)) {
+		return
+	}
+
+	attrs := make([]slog.Attr, len(fields))
+	for idx, field := range fields {
+		attrs[idx] = slog.Any(field.Key, field.Value)
+	}
+
+	l.logger.LogAttrs(ctx, slog.Level(level), "gob: "+msg, attrs...)
+}
@@ -0,0 +1,33 @@
+//go:build go1.21
+// +build go1.21
+
+package gob
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+	logger := NewSlogLogger(slog.New(handler))
+
+	logger.Log(context.Background(), LevelDebug, "batch start", Field{"model", "students"})
+	logger.Log(context.Background(), LevelWarn, "retry transaction", Field{"provider", "PostgreSQL"}, Field{"attempt", 1})
+
+	want := "level=WARN msg=\"gob: retry transaction\" provider=PostgreSQL attempt=1\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("output got: %q want: %q", got, want)
+	}
+}
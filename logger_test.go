@@ -0,0 +1,187 @@
+package gob
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLogEvent recorded by testLogger
+type testLogEvent struct {
+	level  Level
+	msg    string
+	fields map[string]interface{}
+}
+
+type testLogger struct {
+	mu     sync.Mutex
+	events []testLogEvent
+}
+
+func (l *testLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	event := testLogEvent{level: level, msg: msg, fields: make(map[string]interface{})}
+	for _, field := range fields {
+		event.fields[field.Key] = field.Value
+	}
+	l.events = append(l.events, event)
+}
+
+// messages of events at or above level
+func (l *testLogger) messages(level Level) (msgs []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, event := range l.events {
+		if event.level >= level {
+			msgs = append(msgs, event.msg)
+		}
+	}
+
+	return msgs
+}
+
+func TestLogger(t *testing.T) {
+	logger := &testLogger{}
+	gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(10), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	t.Run("commit", func(t *testing.T) {
+		logger.events = nil
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(15),
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		want := []string{"batch start", "batch commit", "batch start", "batch commit", "upsert"}
+		if got := logger.messages(LevelDebug); !reflect.DeepEqual(got, want) {
+			t.Fatalf("events got: %v want: %v", got, want)
+		}
+
+		if got := logger.events[2].fields; got["batch"] != 2 || got["rows"] != 5 || got["model"] != "students" {
+			t.Fatalf("fields of batch start got: %v want: batch 2 of 5 rows of students", got)
+		}
+
+		if got := logger.events[4]; got.level != LevelInfo || got.fields["rows"] != 15 || got.fields["batches"] != 2 {
+			t.Fatalf("upsert event got: %+v want: info of 15 rows in 2 batches", got)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		logger.events = nil
+		err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(1),
+			Keys:           []string{"name"},
+			KeyPredicate:   "age > 0",
+			ConflictAction: ConflictActionUpdate,
+		})
+		if !errors.Is(err, ErrInvalidConflictTarget) {
+			t.Fatalf("upsert err got: %v want: %v", err, ErrInvalidConflictTarget)
+		}
+
+		if got, want := logger.messages(LevelWarn), []string{"batch rollback"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("events got: %v want: %v", got, want)
+		}
+
+		if got := logger.events[1].fields["error"]; got != err {
+			t.Fatalf("error of rollback got: %v want: %v", got, err)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if _, err := New(WithDBProvider(DBProviderMemory), WithLogger(nil)); err == nil {
+			t.Fatalf("init gob with nil logger; want err")
+		}
+	})
+}
+
+func TestLoggerSlowStatement(t *testing.T) {
+	connStr := "file:gobLogger?mode=memory&cache=shared"
+
+	// in-memory database lives as long as a conn is open
+	conn, err := sql.Open("sqlite3", connStr)
+	if err != nil {
+		t.Fatalf("connect to SQLite; err: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec("CREATE TABLE students(name VARCHAR(255) PRIMARY KEY, age INT)"); err != nil {
+		t.Fatalf("create table; err: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		threshold time.Duration
+		want      []string
+	}{
+		{name: "slow", threshold: time.Nanosecond, want: []string{"slow statement"}},
+		{name: "disabled", threshold: 0, want: nil},
+		{name: "fast", threshold: time.Hour, want: nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &testLogger{}
+			gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(connStr), WithLogger(logger), WithSlowStatement(tc.threshold))
+			if err != nil {
+				t.Fatalf("init gob; err: %v", err)
+			}
+			defer gob.Close()
+
+			if err := gob.Upsert(context.Background(), UpsertArgs{
+				Model:          "students",
+				Rows:           []Row{{"name": "name-0", "age": 1}, {"name": "name-1", "age": 2}},
+				Keys:           []string{"name"},
+				ConflictAction: ConflictActionUpdate,
+			}); err != nil {
+				t.Fatalf("upsert rows err: %v", err)
+			}
+
+			if got := logger.messages(LevelWarn); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("events got: %v want: %v", got, tc.want)
+			}
+
+			if tc.want == nil {
+				return
+			}
+
+			fields := logger.events[len(logger.events)-3].fields
+			if fields["provider"] != "SQLite" || !strings.HasPrefix(fields["sql"].(string), "INSERT INTO") {
+				t.Fatalf("fields of slow statement got: %v want: INSERT on SQLite", fields)
+			}
+		})
+	}
+
+	if _, err := New(WithDBProvider(DBProviderMemory), WithSlowStatement(-time.Second)); err == nil {
+		t.Fatalf("init gob with negative threshold; want err")
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), LevelInfo)
+
+	logger.Log(context.Background(), LevelDebug, "batch start", Field{"model", "students"})
+	logger.Log(context.Background(), LevelInfo, "upsert", Field{"model", "students"}, Field{"rows", 10})
+	logger.Log(context.Background(), LevelError, "batch rollback", Field{"error", errors.New("failed")})
+
+	want := "gob: INFO upsert model=students rows=10\ngob: ERROR batch rollback error=failed\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("output got: %q want: %q", got, want)
+	}
+}
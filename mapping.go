@@ -0,0 +1,232 @@
+package gob
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Mapping of source fields to columns of model applied to rows before upsert;
+// fields are dropped, renamed, transformed and then constant and computed columns are added
+type Mapping struct {
+	Drop       []string               `json:"drop,omitempty"`       // source fields removed from rows
+	Rename     map[string]string      `json:"rename,omitempty"`     // source field to column name
+	Transforms map[string][]string    `json:"transforms,omitempty"` // column to transforms applied in order e.g. trim, lower, timestamp:2006-01-02
+	Constants  map[string]interface{} `json:"constants,omitempty"`  // column to constant value e.g. tenant_id
+	Computed   map[string]string      `json:"computed,omitempty"`   // column to generator e.g. now
+}
+
+// Transform converts value of column; arg is the text following ':' in transform name
+type Transform func(value interface{}, arg string) (interface{}, error)
+
+// Generator computes value of column
+type Generator func() interface{}
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]Transform{
+		"trim":      transformString(strings.TrimSpace),
+		"lower":     transformString(strings.ToLower),
+		"upper":     transformString(strings.ToUpper),
+		"timestamp": transformTimestamp,
+	}
+
+	generatorsMu sync.RWMutex
+	generators   = map[string]Generator{
+		"now":  func() interface{} { return time.Now().UTC() },
+		"uuid": func() interface{} { return gocql.TimeUUID() },
+	}
+)
+
+// RegisterTransform makes transform available to mappings by name
+func RegisterTransform(name string, transform Transform) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+
+	transforms[name] = transform
+}
+
+// RegisterGenerator makes generator available to mappings by name
+func RegisterGenerator(name string, generator Generator) {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+
+	generators[name] = generator
+}
+
+func lookupTransform(name string) (Transform, string, bool) {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+
+	var arg string
+	if idx := strings.Index(name, ":"); idx >= 0 {
+		name, arg = name[:idx], name[idx+1:]
+	}
+
+	transform, ok := transforms[name]
+	return transform, arg, ok
+}
+
+func lookupGenerator(name string) (Generator, bool) {
+	generatorsMu.RLock()
+	defer generatorsMu.RUnlock()
+
+	generator, ok := generators[name]
+	return generator, ok
+}
+
+// transformString applies fn to string values; other values are returned as is
+func transformString(fn func(string) string) Transform {
+	return func(value interface{}, arg string) (interface{}, error) {
+		if s, ok := value.(string); ok {
+			return fn(s), nil
+		}
+
+		return value, nil
+	}
+}
+
+// transformTimestamp parses string values with layout arg; defaults to RFC3339
+func transformTimestamp(value interface{}, arg string) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+
+	layout := arg
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+
+	t, err := time.Parse(layout, strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("parse timestamp %s: %w", s, err)
+	}
+
+	return t, nil
+}
+
+// LoadMappings decodes mappings of models from JSON
+func LoadMappings(r io.Reader) (map[string]Mapping, error) {
+	var mappings map[string]Mapping
+	if err := json.NewDecoder(r).Decode(&mappings); err != nil {
+		return nil, fmt.Errorf("gob: decode mappings: %w", err)
+	}
+
+	for model, mapping := range mappings {
+		if err := mapping.validate(); err != nil {
+			return nil, fmt.Errorf("%w model '%s'", err, model)
+		}
+	}
+
+	return mappings, nil
+}
+
+// validate verifies transforms and generators of mapping are registered
+func (mapping Mapping) validate() error {
+	for column, names := range mapping.Transforms {
+		for _, name := range names {
+			if _, _, ok := lookupTransform(name); !ok {
+				return fmt.Errorf("gob: invalid transform '%s' of column '%s';", name, column)
+			}
+		}
+	}
+
+	for column, name := range mapping.Computed {
+		if _, ok := lookupGenerator(name); !ok {
+			return fmt.Errorf("gob: invalid generator '%s' of column '%s';", name, column)
+		}
+	}
+
+	return nil
+}
+
+// apply returns rows mapped to columns of model; rows of caller are not modified;
+// offset is the index of first row in UpsertArgs.Rows
+func (mapping Mapping) apply(model string, rows []Row, offset int) ([]Row, error) {
+	var (
+		mapped  = make([]Row, len(rows))
+		rowErrs []RowError
+	)
+
+	for idx, row := range rows {
+		if row.Len() == 0 {
+			continue // ignore empty row
+		}
+
+		result, problems := mapping.applyRow(row)
+		if len(problems) > 0 {
+			rowErrs = append(rowErrs, RowError{Index: offset + idx, Problems: problems})
+			continue
+		}
+
+		mapped[idx] = result
+	}
+
+	if len(rowErrs) > 0 {
+		return nil, &ValidationError{Model: model, Rows: rowErrs}
+	}
+
+	return mapped, nil
+}
+
+func (mapping Mapping) applyRow(row Row) (Row, []string) {
+	var (
+		result   = copyRow(row)
+		problems []string
+	)
+
+	for _, field := range mapping.Drop {
+		delete(result, field)
+	}
+
+	// remove all renamed fields before adding columns to allow swapping names
+	renamed := make(Row, len(mapping.Rename))
+	for field, column := range mapping.Rename {
+		if value, ok := result[field]; ok {
+			renamed[column] = value
+			delete(result, field)
+		}
+	}
+
+	for column, value := range renamed {
+		result[column] = value
+	}
+
+	for column, names := range mapping.Transforms {
+		value, ok := result[column]
+		if !ok || value == nil {
+			continue
+		}
+
+		for _, name := range names {
+			transform, arg, _ := lookupTransform(name)
+
+			var err error
+			if value, err = transform(value, arg); err != nil {
+				problems = append(problems, fmt.Sprintf("column '%s' transform '%s': %v", column, name, err))
+				break
+			}
+		}
+
+		result[column] = value
+	}
+
+	for column, value := range mapping.Constants {
+		result[column] = value
+	}
+
+	for column, name := range mapping.Computed {
+		generator, _ := lookupGenerator(name)
+		result[column] = generator()
+	}
+
+	sort.Strings(problems)
+	return result, problems
+}
@@ -0,0 +1,110 @@
+package gob
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMapping(t *testing.T) {
+	mappings, err := LoadMappings(strings.NewReader(`{
+		"students": {
+			"drop": ["internal"],
+			"rename": {"full_name": "name", "dob": "birthday", "name": "nickname"},
+			"transforms": {"name": ["trim", "lower"], "birthday": ["timestamp:2006-01-02"]},
+			"constants": {"tenant_id": "tenant-0"},
+			"computed": {"loaded_at": "now"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("load mappings err: %v", err)
+	}
+
+	mapping := mappings["students"]
+
+	t.Run("apply", func(t *testing.T) {
+		row := Row{"full_name": " Name-0 ", "name": "foo", "dob": "2000-01-02", "internal": true, "age": 10}
+
+		got, err := mapping.apply("students", []Row{row}, 0)
+		if err != nil {
+			t.Fatalf("apply mapping err: %v", err)
+		}
+
+		loadedAt, ok := got[0].Value("loaded_at").(time.Time)
+		if !ok || loadedAt.IsZero() {
+			t.Fatalf("loaded_at got: %v want: current time", got[0].Value("loaded_at"))
+		}
+
+		want := Row{
+			"name":      "name-0",
+			"nickname":  "foo",
+			"birthday":  time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC),
+			"age":       10,
+			"tenant_id": "tenant-0",
+			"loaded_at": loadedAt,
+		}
+
+		if !reflect.DeepEqual(got[0], want) {
+			t.Fatalf("row got: %v want: %v", got[0], want)
+		}
+
+		if row.Len() != 5 {
+			t.Fatalf("row of caller modified: %v", row)
+		}
+	})
+
+	t.Run("transformErr", func(t *testing.T) {
+		rows := []Row{{"full_name": "name-0"}, {"full_name": "name-1", "dob": "01/02/2000"}}
+
+		_, err := mapping.apply("students", rows, 10)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("apply mapping got: %v want: %v", err, ErrInvalidRows)
+		}
+
+		if len(validationErr.Rows) != 1 || validationErr.Rows[0].Index != 11 {
+			t.Fatalf("row errors got: %+v want: row 11", validationErr.Rows)
+		}
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		RegisterTransform("reverse", func(value interface{}, arg string) (interface{}, error) {
+			s := []rune(value.(string))
+			for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+				s[i], s[j] = s[j], s[i]
+			}
+			return string(s), nil
+		})
+		RegisterGenerator("source", func() interface{} { return "upstream" })
+
+		m := Mapping{Transforms: map[string][]string{"name": {"reverse"}}, Computed: map[string]string{"source": "source"}}
+		if err := WithMapping("students", m)(defaultGob()); err != nil {
+			t.Fatalf("mapping with registered transform err: %v", err)
+		}
+
+		got, err := m.apply("students", []Row{{"name": "abc"}}, 0)
+		if err != nil {
+			t.Fatalf("apply mapping err: %v", err)
+		}
+
+		if want := (Row{"name": "cba", "source": "upstream"}); !reflect.DeepEqual(got[0], want) {
+			t.Fatalf("row got: %v want: %v", got[0], want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := LoadMappings(strings.NewReader(`{"students": {"transforms": {"name": ["title"]}}}`)); err == nil {
+			t.Fatalf("unknown transform; want err")
+		}
+
+		if err := WithMapping("students", Mapping{Computed: map[string]string{"loaded_at": "today"}})(defaultGob()); err == nil {
+			t.Fatalf("unknown generator; want err")
+		}
+
+		if err := WithMappings(map[string]Mapping{"": {}})(defaultGob()); !errors.Is(err, ErrEmptyModel) {
+			t.Fatalf("empty model got: %v want: %v", err, ErrEmptyModel)
+		}
+	})
+}
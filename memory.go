@@ -0,0 +1,402 @@
+package gob
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Memory stores models in memory with upsert semantics of databases; each connection of DBProviderMemory
+// is a new empty Memory available from Gob.Provider to inspect upserted rows in tests
+type Memory struct {
+	mu     sync.RWMutex
+	models map[string]*memoryModel
+}
+
+// memoryModel holds rows of model in insertion order
+type memoryModel struct {
+	columns    []Column
+	primaryKey []string
+	uniqueKeys [][]string // keys of upserts other than primary key
+	rows       []Row
+	indexes    map[string]map[string]int // row index by values of keys per sorted keys
+}
+
+func init() {
+	Register(DBProviderMemory, newMemory)
+}
+
+func newMemory(args ConnArgs) (Provider, error) {
+	return NewMemory(), nil
+}
+
+// NewMemory returns empty Memory
+func NewMemory() *Memory {
+	return &Memory{models: make(map[string]*memoryModel)}
+}
+
+// Close keeps models readable; Gob.Close drops the provider
+func (m *Memory) Close() {}
+
+// Upsert rows to model atomically; model is created on first upsert with primary key of keys and keys other
+// than primary key are kept as unique key of model. Rows with a key missing or nil never conflict like NULL keys
+// of relational databases; rows with values of primary key or a unique key of another row fail with ErrInvalidRows
+func (m *Memory) Upsert(ctx context.Context, args UpsertArgs) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if args.Constraint != "" || args.KeyPredicate != "" {
+		return fmt.Errorf("%w constraint and key predicate not supported by memory provider", ErrInvalidConflictTarget)
+	}
+
+	if len(args.Keys) == 0 {
+		return ErrEmptykeys
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	model, ok := m.models[args.Model]
+	if !ok {
+		model = &memoryModel{primaryKey: args.Keys}
+		for _, key := range args.Keys {
+			model.addColumn(key, nil, true)
+		}
+		m.models[args.Model] = model
+	}
+
+	// rows are replaced on update so copy of slices restores model
+	saved := memoryModel{
+		columns:    append([]Column(nil), model.columns...),
+		primaryKey: model.primaryKey,
+		uniqueKeys: append([][]string(nil), model.uniqueKeys...),
+		rows:       append([]Row(nil), model.rows...),
+	}
+
+	model.addUniqueKey(args.Keys)
+	for idx, row := range args.Rows {
+		if row.Len() == 0 {
+			continue // ignore empty row
+		}
+
+		if keys, ok := model.upsert(row, args.Keys, args.ConflictAction); !ok {
+			*model = saved
+			return fmt.Errorf("%w model '%s' row %d: duplicate value of key (%s)", ErrInvalidRows, args.Model, idx, strings.Join(keys, ","))
+		}
+	}
+
+	return nil
+}
+
+// Metadata of model created by CreateModel or first upsert; columns in order of addition
+func (m *Memory) Metadata(ctx context.Context, model string) (*Metadata, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.models[model]
+	if !ok {
+		return nil, fmt.Errorf("%w model '%s' in memory", ErrModelNotFound, model)
+	}
+
+	return &Metadata{
+		Model:      model,
+		Columns:    append([]Column(nil), stored.columns...),
+		PrimaryKey: append([]string(nil), stored.primaryKey...),
+		UniqueKeys: append([][]string(nil), stored.uniqueKeys...),
+	}, nil
+}
+
+func (m *Memory) AddColumn(ctx context.Context, model, column string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.models[model]
+	if !ok {
+		return fmt.Errorf("gob: add column '%s': %w model '%s' in memory", column, ErrModelNotFound, model)
+	}
+
+	stored.addColumn(column, value, false)
+	return nil
+}
+
+func (m *Memory) CreateModel(ctx context.Context, args UpsertArgs, sampleSize int) error {
+	schema, err := inferModelSchema(args, sampleSize)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.models[schema.model]; ok {
+		return nil
+	}
+
+	model := &memoryModel{primaryKey: schema.keys}
+	for _, column := range schema.columns {
+		model.columns = append(model.columns, Column{
+			Name:     column.name,
+			Type:     memoryTypeName(column.typ),
+			Nullable: !column.key,
+			typ:      column.typ,
+		})
+	}
+	m.models[schema.model] = model
+
+	return nil
+}
+
+// Models returns sorted names of models
+func (m *Memory) Models() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var models []string
+	for model := range m.models {
+		models = append(models, model)
+	}
+
+	sort.Strings(models)
+	return models
+}
+
+// Rows returns copy of rows of model in insertion order
+func (m *Memory) Rows(model string) []Row {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.models[model]
+	if !ok {
+		return nil
+	}
+
+	rows := make([]Row, len(stored.rows))
+	for idx, row := range stored.rows {
+		rows[idx] = copyRow(row)
+	}
+
+	return rows
+}
+
+// Get returns copy of row of model with values of key columns in key e.g. Row{"name": "name-0"}
+func (m *Memory) Get(model string, key Row) (Row, bool) {
+	m.mu.Lock() // index may be built
+	defer m.mu.Unlock()
+
+	stored, ok := m.models[model]
+	if !ok {
+		return nil, false
+	}
+
+	idx, ok := stored.find(key, key.Columns())
+	if !ok {
+		return nil, false
+	}
+
+	return copyRow(stored.rows[idx]), true
+}
+
+// Len returns number of rows of model
+func (m *Memory) Len(model string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if stored, ok := m.models[model]; ok {
+		return len(stored.rows)
+	}
+
+	return 0
+}
+
+// Reset drops all models
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.models = make(map[string]*memoryModel)
+}
+
+// upsert row resolving conflict on keys with action; returns primary key or unique key of model with values of
+// row in another row and false
+func (model *memoryModel) upsert(row Row, keys []string, action ConflictAction) ([]string, bool) {
+	for _, column := range row.Columns() {
+		model.addColumn(column, row.Value(column), false)
+	}
+
+	idx, found := model.find(row, keys)
+	if !found {
+		if duplicate, ok := model.unique(row, -1); !ok {
+			return duplicate, false
+		}
+
+		model.insert(row)
+		return nil, true
+	}
+
+	if action != ConflictActionUpdate {
+		return nil, true
+	}
+
+	// columns absent from row are left unchanged
+	updated := copyRow(model.rows[idx])
+	for column, value := range row {
+		updated[column] = value
+	}
+
+	if duplicate, ok := model.unique(updated, idx); !ok {
+		return duplicate, false
+	}
+
+	model.rows[idx] = updated
+
+	// values of other keys may have changed
+	signature := memorySignature(keys)
+	for other := range model.indexes {
+		if other != signature {
+			delete(model.indexes, other)
+		}
+	}
+
+	return nil, true
+}
+
+// unique returns primary key or unique key of model with values of row in a row other than row at idx and false
+func (model *memoryModel) unique(row Row, idx int) ([]string, bool) {
+	for _, keys := range append([][]string{model.primaryKey}, model.uniqueKeys...) {
+		if other, found := model.find(row, keys); found && other != idx {
+			return keys, false
+		}
+	}
+
+	return nil, true
+}
+
+// addUniqueKey adds keys unless primary key or unique key of model
+func (model *memoryModel) addUniqueKey(keys []string) {
+	signature := memorySignature(keys)
+	if signature == memorySignature(model.primaryKey) {
+		return
+	}
+
+	for _, unique := range model.uniqueKeys {
+		if memorySignature(unique) == signature {
+			return
+		}
+	}
+
+	model.uniqueKeys = append(model.uniqueKeys, append([]string(nil), keys...))
+}
+
+func (model *memoryModel) insert(row Row) {
+	row = copyRow(row)
+	model.rows = append(model.rows, row)
+
+	for signature, index := range model.indexes {
+		if value, ok := memoryKeyValue(row, strings.Split(signature, ",")); ok {
+			index[value] = len(model.rows) - 1
+		}
+	}
+}
+
+// find returns index of row with values of keys of row
+func (model *memoryModel) find(row Row, keys []string) (int, bool) {
+	value, ok := memoryKeyValue(row, keys)
+	if !ok {
+		return 0, false
+	}
+
+	idx, ok := model.index(keys)[value]
+	return idx, ok
+}
+
+// index returns row index by values of keys; built on first use
+func (model *memoryModel) index(keys []string) map[string]int {
+	signature := memorySignature(keys)
+	if index, ok := model.indexes[signature]; ok {
+		return index
+	}
+
+	if model.indexes == nil {
+		model.indexes = make(map[string]map[string]int)
+	}
+
+	index := make(map[string]int, len(model.rows))
+	for idx, row := range model.rows {
+		if value, ok := memoryKeyValue(row, keys); ok {
+			index[value] = idx
+		}
+	}
+
+	model.indexes[signature] = index
+	return index
+}
+
+// addColumn adds column with type of value unless known; type of column seen only with nil values is refined
+func (model *memoryModel) addColumn(name string, value interface{}, key bool) {
+	typ := inferType(value)
+	for idx, column := range model.columns {
+		if column.Name == name {
+			if column.typ.kind == kindAny && typ.kind != kindAny {
+				model.columns[idx].typ = typ
+				model.columns[idx].Type = memoryTypeName(typ)
+			}
+			return
+		}
+	}
+
+	model.columns = append(model.columns, Column{Name: name, Type: memoryTypeName(typ), Nullable: !key, typ: typ})
+}
+
+// memorySignature of keys independent of key order
+func memorySignature(keys []string) string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// memoryKeyValue returns values of keys of row in sorted key order encoded by encodeKey; values bound alike by
+// drivers e.g. int and int64 or pointer and its value are the same key
+func memoryKeyValue(row Row, keys []string) (string, bool) {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	return encodeKey(row, sorted)
+}
+
+// memoryTypeName returns name of column type reported by Metadata
+func memoryTypeName(typ columnType) string {
+	switch typ.kind {
+	case kindString:
+		return "string"
+	case kindInt:
+		return fmt.Sprintf("int%d", typ.bits)
+	case kindFloat:
+		return "float"
+	case kindBool:
+		return "bool"
+	case kindTime:
+		return "time"
+	case kindDate:
+		return "date"
+	case kindUUID:
+		return "uuid"
+	case kindBytes:
+		return "bytes"
+	case kindJSON:
+		return "json"
+	case kindList:
+		return "list"
+	case kindMap:
+		return "map"
+	}
+
+	return "any"
+}
+
+// requiresConflictTarget of keys
+func (m *Memory) requiresConflictTarget() bool {
+	return true
+}
@@ -0,0 +1,255 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemory()
+	defer db.Close()
+
+	upsert := func(t *testing.T, action ConflictAction, rows ...Row) {
+		if err := db.Upsert(ctx, UpsertArgs{Model: "students", Keys: []string{"name"}, ConflictAction: action, Rows: rows}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		upsert(t, ConflictActionUpdate, Row{"name": "name-0", "age": 0}, Row{}, Row{"name": "name-1", "age": 1})
+
+		want := []Row{{"name": "name-0", "age": 0}, {"name": "name-1", "age": 1}}
+		if got := db.Rows("students"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("rows got: %v want: %v", got, want)
+		}
+	})
+
+	t.Run("conflictActionUpdate", func(t *testing.T) {
+		upsert(t, ConflictActionUpdate, Row{"name": "name-0", "age": 10, "nickname": "nickname-0"}, Row{"name": "name-1", "nickname": "nickname-1"})
+
+		want := []Row{{"name": "name-0", "age": 10, "nickname": "nickname-0"}, {"name": "name-1", "age": 1, "nickname": "nickname-1"}}
+		if got := db.Rows("students"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("rows got: %v want: %v", got, want)
+		}
+	})
+
+	t.Run("conflictActionNothing", func(t *testing.T) {
+		upsert(t, ConflictActionNothing, Row{"name": "name-0", "age": 20}, Row{"name": "name-2", "age": 2})
+
+		if got, _ := db.Get("students", Row{"name": "name-0"}); got.Value("age") != 10 {
+			t.Fatalf("age of name-0 got: %v want: 10", got.Value("age"))
+		}
+
+		if got, ok := db.Get("students", Row{"name": "name-2"}); !ok || got.Value("age") != 2 {
+			t.Fatalf("row name-2 got: %v want age: 2", got)
+		}
+	})
+
+	t.Run("nilKey", func(t *testing.T) {
+		upsert(t, ConflictActionUpdate, Row{"name": nil, "age": 3}, Row{"name": nil, "age": 4})
+
+		if got := db.Len("students"); got != 5 {
+			t.Fatalf("len got: %d want: 5", got)
+		}
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		row, _ := db.Get("students", Row{"name": "name-0"})
+		row["age"] = 100
+
+		if got, _ := db.Get("students", Row{"name": "name-0"}); got.Value("age") != 10 {
+			t.Fatalf("age of name-0 got: %v want: 10", got.Value("age"))
+		}
+	})
+
+	t.Run("invalidConflictTarget", func(t *testing.T) {
+		rows := []Row{{"name": "name-0"}}
+		for _, args := range []UpsertArgs{
+			{Model: "students", Keys: []string{"name"}, Constraint: "students_name_key", Rows: rows},
+			{Model: "students", Keys: []string{"name"}, KeyPredicate: "age > 0", Rows: rows},
+		} {
+			if err := db.Upsert(ctx, args); !errors.Is(err, ErrInvalidConflictTarget) {
+				t.Fatalf("upsert with %+v got: %v want: %v", args, err, ErrInvalidConflictTarget)
+			}
+		}
+
+		if err := db.Upsert(ctx, UpsertArgs{Model: "students", Rows: rows}); !errors.Is(err, ErrEmptykeys) {
+			t.Fatalf("upsert without keys got: %v want: %v", err, ErrEmptykeys)
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		if _, err := db.Metadata(ctx, "teachers"); !errors.Is(err, ErrModelNotFound) {
+			t.Fatalf("metadata of unknown model got: %v want: %v", err, ErrModelNotFound)
+		}
+
+		got, err := db.Metadata(ctx, "students")
+		if err != nil {
+			t.Fatalf("metadata err: %v", err)
+		}
+
+		want := &Metadata{
+			Model: "students",
+			Columns: []Column{
+				{Name: "name", Type: "string", typ: columnType{kind: kindString}},
+				{Name: "age", Type: "int64", Nullable: true, typ: columnType{kind: kindInt, bits: 64}},
+				{Name: "nickname", Type: "string", Nullable: true, typ: columnType{kind: kindString}},
+			},
+			PrimaryKey: []string{"name"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("metadata got: %+v want: %+v", got, want)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		db.Reset()
+
+		if got := db.Models(); len(got) != 0 {
+			t.Fatalf("models got: %v want none", got)
+		}
+	})
+}
+
+func TestMemoryKeyCollisions(t *testing.T) {
+	id, otherID := 1, 1
+	for _, test := range []struct {
+		name string
+		keys []string
+		rows []Row
+		want int
+	}{
+		{"compositeSeparator", []string{"first", "last"}, []Row{{"first": "a b", "last": "c"}, {"first": "a", "last": "b c"}}, 2},
+		{"intInt64", []string{"id"}, []Row{{"id": 1}, {"id": int64(1)}}, 1},
+		{"uintInt8", []string{"id"}, []Row{{"id": uint(1)}, {"id": int8(1)}}, 1},
+		{"pointer", []string{"id"}, []Row{{"id": &id}, {"id": &otherID}, {"id": 1}}, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			db := NewMemory()
+			defer db.Close()
+
+			if err := db.Upsert(context.Background(), UpsertArgs{Model: "students", Keys: test.keys, ConflictAction: ConflictActionUpdate, Rows: test.rows}); err != nil {
+				t.Fatalf("upsert rows err: %v", err)
+			}
+
+			if got := db.Len("students"); got != test.want {
+				t.Fatalf("len got: %d want: %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMemoryUniqueKeys(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemory()
+	defer db.Close()
+
+	rows := []Row{{"id": 0, "name": "name-0"}, {"id": 1, "name": "name-1"}}
+	if err := db.Upsert(ctx, UpsertArgs{Model: "students", Keys: []string{"id"}, ConflictAction: ConflictActionUpdate, Rows: rows}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	if err := db.Upsert(ctx, UpsertArgs{Model: "students", Keys: []string{"name"}, ConflictAction: ConflictActionUpdate, Rows: []Row{{"id": 2, "name": "name-2"}}}); err != nil {
+		t.Fatalf("upsert rows on unique key err: %v", err)
+	}
+
+	if got, err := db.Metadata(ctx, "students"); err != nil || !reflect.DeepEqual(got.UniqueKeys, [][]string{{"name"}}) {
+		t.Fatalf("unique keys got: %+v, %v want: [[name]]", got, err)
+	}
+
+	for _, test := range []struct {
+		name string
+		keys []string
+		row  Row
+	}{
+		{"insertPrimaryKey", []string{"name"}, Row{"id": 0, "name": "name-3"}},
+		{"updatePrimaryKey", []string{"name"}, Row{"id": 0, "name": "name-1"}},
+		{"insertUniqueKey", []string{"id"}, Row{"id": 3, "name": "name-0"}},
+		{"updateUniqueKey", []string{"id"}, Row{"id": 1, "name": "name-0"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := db.Upsert(ctx, UpsertArgs{
+				Model:          "students",
+				Keys:           test.keys,
+				ConflictAction: ConflictActionUpdate,
+				Rows:           []Row{{"id": 4, "name": "name-4"}, test.row},
+			})
+			if !errors.Is(err, ErrInvalidRows) {
+				t.Fatalf("upsert got: %v want: %v", err, ErrInvalidRows)
+			}
+
+			// upsert is atomic
+			want := []Row{{"id": 0, "name": "name-0"}, {"id": 1, "name": "name-1"}, {"id": 2, "name": "name-2"}}
+			if got := db.Rows("students"); !reflect.DeepEqual(got, want) {
+				t.Fatalf("rows got: %v want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGobMemory(t *testing.T) {
+	gob, err := New(WithDBProvider(DBProviderMemory), WithCreateModel(10), WithValidation(true), WithBatchSize(10))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+
+	db := gob.Provider().(*Memory)
+	rows := testGenStudentRowsMySQL(25)
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	// keys default to primary key of model created
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           []Row{{"name": "name-0", "age": 100}},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	if got := db.Models(); !reflect.DeepEqual(got, []string{"students"}) {
+		t.Fatalf("models got: %v want: [students]", got)
+	}
+
+	if got := db.Len("students"); got != len(rows) {
+		t.Fatalf("len got: %d want: %d", got, len(rows))
+	}
+
+	if got, _ := db.Get("students", Row{"name": "name-0"}); got.Value("age") != int64(100) {
+		t.Fatalf("age of name-0 got: %#v want: 100", got.Value("age"))
+	}
+
+	gob.Close()
+	if got := db.Len("students"); got != len(rows) {
+		t.Fatalf("len after close got: %d want: %d", got, len(rows))
+	}
+}
+
+func TestPingStatsMemory(t *testing.T) {
+	gob, err := New(WithDBProvider(DBProviderMemory))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+
+	if err := gob.Ping(context.Background()); err != nil {
+		t.Fatalf("ping err: %v", err)
+	}
+
+	if stats := gob.Stats(); stats != (PoolStats{}) {
+		t.Fatalf("stats got: %+v want: zero", stats)
+	}
+
+	gob.Close()
+	if err := gob.Ping(context.Background()); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("ping after close got: %v want: %v", err, ErrConnClosed)
+	}
+}
@@ -0,0 +1,130 @@
+package gob
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Metadata of model loaded from database catalog
+type Metadata struct {
+	Model      string     // table name
+	Columns    []Column   // columns in table order
+	PrimaryKey []string   // primary key column names in key order
+	UniqueKeys [][]string // column names of unique constraints excluding primary key
+}
+
+// Column of model
+type Column struct {
+	Name     string     // column name
+	Type     string     // database type name
+	Nullable bool       // column accepts NULL
+	Default  bool       // column has default value
+	typ      columnType // type used for validation
+}
+
+// columnSet returns columns of model by name
+func (metadata *Metadata) columnSet() map[string]Column {
+	columns := make(map[string]Column, len(metadata.Columns))
+	for _, column := range metadata.Columns {
+		columns[column.Name] = column
+	}
+
+	return columns
+}
+
+// metadataCache holds metadata per model
+type metadataCache struct {
+	mu     sync.RWMutex
+	models map[string]*Metadata
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{models: make(map[string]*Metadata)}
+}
+
+func (cache *metadataCache) get(model string) (*Metadata, bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	metadata, ok := cache.models[model]
+	return metadata, ok
+}
+
+func (cache *metadataCache) set(model string, metadata *Metadata) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.models[model] = metadata
+}
+
+// reset removes models from cache; all models if none specified
+func (cache *metadataCache) reset(models ...string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if len(models) == 0 {
+		cache.models = make(map[string]*Metadata)
+		return
+	}
+
+	for _, model := range models {
+		delete(cache.models, model)
+	}
+}
+
+// splitModel splits schema qualified model into schema and table
+func splitModel(model string) (schema, table string) {
+	if idx := strings.LastIndex(model, "."); idx >= 0 {
+		return model[:idx], model[idx+1:]
+	}
+
+	return "", model
+}
+
+// Metadata returns metadata of model loaded from database on first use
+func (gob *Gob) Metadata(ctx context.Context, model string) (*Metadata, error) {
+	if model == "" {
+		return nil, ErrEmptyModel
+	}
+
+	if metadata, ok := gob.metadata.get(model); ok {
+		return metadata, nil
+	}
+
+	return gob.loadMetadata(ctx, model)
+}
+
+// RefreshMetadata reloads metadata of models; drops metadata of all models if none specified
+func (gob *Gob) RefreshMetadata(ctx context.Context, models ...string) error {
+	gob.metadata.reset(models...)
+	for _, model := range models {
+		if _, err := gob.loadMetadata(ctx, model); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gob *Gob) loadMetadata(ctx context.Context, model string) (*Metadata, error) {
+	provider := gob.getProvider()
+	// conn closed
+	if provider == nil {
+		return nil, ErrConnClosed
+	}
+
+	metadataProvider, ok := provider.(MetadataProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w read metadata of model '%s' with provider %s", ErrUnsupported, model, gob.dbProvider)
+	}
+
+	metadata, err := metadataProvider.Metadata(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	gob.metadata.set(model, metadata)
+	return metadata, nil
+}
@@ -0,0 +1,121 @@
+package gob
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+)
+
+// Classes of errors of failed batches reported to Metrics
+const (
+	ErrorClassCanceled      = "canceled"
+	ErrorClassTimeout       = "timeout"
+	ErrorClassInvalidArgs   = "invalid_args"
+	ErrorClassInvalidRows   = "invalid_rows"
+	ErrorClassModelNotFound = "model_not_found"
+	ErrorClassConn          = "conn"
+	ErrorClassSerialization = "serialization"
+	ErrorClassDatabase      = "database"
+)
+
+// Metrics receives measurements of upserts; implementations must be safe for concurrent use
+type Metrics interface {
+	// Batch observes batch of rows of model upserted by provider in d; err is nil if batch is committed
+	Batch(provider DBProvider, model string, rows int, d time.Duration, err error)
+
+	// Retry counts retry of transaction of model by provider
+	Retry(provider DBProvider, model string)
+
+	// Pool observes connections of pool of provider after each batch
+	Pool(provider DBProvider, stats PoolStats)
+}
+
+// PoolStats of connections of provider to database
+type PoolStats struct {
+	Open         int           // connections open to database
+	Idle         int           // connections idle in pool
+	InUse        int           // connections in use
+	WaitCount    int64         // total number of connections waited for
+	WaitDuration time.Duration // total time waited for connections
+	HostsUp      int           // hosts of Cassandra up
+	HostsDown    int           // hosts of Cassandra down
+}
+
+// nopMetrics discards measurements; default Metrics of Gob
+type nopMetrics struct{}
+
+func (nopMetrics) Batch(provider DBProvider, model string, rows int, d time.Duration, err error) {}
+
+func (nopMetrics) Retry(provider DBProvider, model string) {}
+
+func (nopMetrics) Pool(provider DBProvider, stats PoolStats) {}
+
+// ErrorClass returns class of error of failed batch e.g. ErrorClassTimeout
+func ErrorClass(err error) string {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, ErrEmptyModel), errors.Is(err, ErrEmptykeys), errors.Is(err, ErrEmptyConflictAction),
+		errors.Is(err, ErrInvalidConflictTarget), errors.Is(err, ErrEmptyRows), errors.Is(err, ErrUnsupported):
+		return ErrorClassInvalidArgs
+	case errors.Is(err, ErrInvalidRows):
+		return ErrorClassInvalidRows
+	case errors.Is(err, ErrModelNotFound):
+		return ErrorClassModelNotFound
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, ErrConnClosed), errors.Is(err, ErrCircuitOpen), errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return ErrorClassConn
+	case pgRetryable(err):
+		return ErrorClassSerialization
+	}
+
+	return ErrorClassDatabase
+}
+
+// sqlPoolStats returns stats of pool of database/sql
+func sqlPoolStats(db *sql.DB) PoolStats {
+	stats := db.Stats()
+	return PoolStats{
+		Open:         stats.OpenConnections,
+		Idle:         stats.Idle,
+		InUse:        stats.InUse,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+}
+
+func (db *sqlDB) Stats() PoolStats {
+	return sqlPoolStats(db.DB)
+}
+
+func (db *mssql) Stats() PoolStats {
+	return sqlPoolStats(db.DB)
+}
+
+// Stats of Cassandra are hosts of ring up and down; gocql does not expose its connections
+func (db *cassy) Stats() PoolStats {
+	var stats PoolStats
+	stats.HostsUp, stats.HostsDown = db.hosts.count()
+	return stats
+}
+
+// Stats of pgxpool; connections waited for are acquires from empty pool and
+// time waited is the total time of acquires
+func (db *pg) Stats() PoolStats {
+	stat := db.Stat()
+	return PoolStats{
+		Open:         int(stat.TotalConns()),
+		Idle:         int(stat.IdleConns()),
+		InUse:        int(stat.AcquiredConns()),
+		WaitCount:    stat.EmptyAcquireCount(),
+		WaitDuration: stat.AcquireDuration(),
+	}
+}
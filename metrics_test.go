@@ -0,0 +1,137 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+)
+
+// testMetrics records measurements
+type testMetrics struct {
+	mu      sync.Mutex
+	batches []string
+	retries int
+	pools   []PoolStats
+}
+
+func (m *testMetrics) Batch(provider DBProvider, model string, rows int, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	class := ""
+	if err != nil {
+		class = ErrorClass(err)
+	}
+	m.batches = append(m.batches, fmt.Sprintf("%s/%s/%d/%s", provider, model, rows, class))
+}
+
+func (m *testMetrics) Retry(provider DBProvider, model string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.retries++
+}
+
+func (m *testMetrics) Pool(provider DBProvider, stats PoolStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pools = append(m.pools, stats)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		metrics := &testMetrics{}
+		gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(10), WithMetrics(metrics))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(15),
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(1),
+			Keys:           []string{"name"},
+			KeyPredicate:   "age > 0",
+			ConflictAction: ConflictActionUpdate,
+		})
+
+		want := []string{"memory/students/10/", "memory/students/5/", "memory/students/1/invalid_args"}
+		if !reflect.DeepEqual(metrics.batches, want) {
+			t.Fatalf("batches got: %v want: %v", metrics.batches, want)
+		}
+
+		// memory has no pool
+		if len(metrics.pools) != 0 {
+			t.Fatalf("pools got: %v want: none", metrics.pools)
+		}
+	})
+
+	t.Run("pool", func(t *testing.T) {
+		if err := setupSQLiteDB(); err != nil {
+			t.Fatalf("setup sqlite; err: %v", err)
+		}
+
+		metrics := &testMetrics{}
+		gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr), WithMetrics(metrics))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsSQLite(2),
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		if len(metrics.pools) != 1 || metrics.pools[0].Open != 1 || metrics.pools[0].InUse != 0 {
+			t.Fatalf("pools got: %+v want: 1 open conn not in use", metrics.pools)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if _, err := New(WithDBProvider(DBProviderMemory), WithMetrics(nil)); err == nil {
+			t.Fatalf("init gob with nil metrics; want err")
+		}
+	})
+}
+
+func TestErrorClass(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{err: fmt.Errorf("gob: execute: %w", context.Canceled), want: ErrorClassCanceled},
+		{err: context.DeadlineExceeded, want: ErrorClassTimeout},
+		{err: ErrEmptykeys, want: ErrorClassInvalidArgs},
+		{err: fmt.Errorf("%w invalid key", ErrInvalidConflictTarget), want: ErrorClassInvalidArgs},
+		{err: fmt.Errorf("%w row 1", ErrInvalidRows), want: ErrorClassInvalidRows},
+		{err: ErrModelNotFound, want: ErrorClassModelNotFound},
+		{err: ErrConnClosed, want: ErrorClassConn},
+		{err: fmt.Errorf("gob: commit: %w", &pgconn.PgError{Code: pgSerializationFailure}), want: ErrorClassSerialization},
+		{err: errors.New("syntax error"), want: ErrorClassDatabase},
+	} {
+		if got := ErrorClass(tc.err); got != tc.want {
+			t.Errorf("class of %v got: %s want: %s", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package gob
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/csmadhu/gob/internal/hooks"
+)
+
+// mssqlColumnsSQL lists columns of user table in table order
+const mssqlColumnsSQL = `SELECT c.name, TYPE_NAME(c.system_type_id), c.is_nullable,
+	CAST(CASE WHEN c.default_object_id <> 0 OR c.is_identity = 1 OR c.is_computed = 1 THEN 1 ELSE 0 END AS bit)
+FROM sys.columns c
+WHERE c.object_id = OBJECT_ID(@p1, 'U')
+ORDER BY c.column_id`
+
+// mssqlKeysSQL lists columns of unique indexes of user table; filtered indexes are skipped
+const mssqlKeysSQL = `SELECT i.name, i.is_primary_key, c.name
+FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.object_id = OBJECT_ID(@p1, 'U') AND i.is_unique = 1 AND i.has_filter = 0 AND ic.is_included_column = 0
+ORDER BY i.is_primary_key DESC, i.name, ic.key_ordinal`
+
+type mssql struct {
+	*sql.DB
+	observer observer
+}
+
+var (
+	// mssqlCopyIn returns statement bulk copying columns to table keeping NULL values; set with the driver by
+	// package github.com/csmadhu/gob/mssql
+	mssqlCopyIn func(table string, columns ...string) string
+
+	// mssqlRowError reports whether err is caused by values of a row e.g. violation of primary key; set with the
+	// driver by package github.com/csmadhu/gob/mssql
+	mssqlRowError = func(err error) bool { return false }
+)
+
+// provider of SQL Server is registered by package github.com/csmadhu/gob/mssql importing the driver
+func init() {
+	hooks.RegisterMSSQL = func(copyIn func(table string, columns ...string) string, rowError func(err error) bool) {
+		mssqlCopyIn, mssqlRowError = copyIn, rowError
+		Register(DBProviderMSSQL, newMSSQL)
+	}
+}
+
+func newMSSQL(args ConnArgs) (Provider, error) {
+	var (
+		m   mssql
+		err error
+	)
+
+	m.DB, err = sql.Open("sqlserver", args.ConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("gob: connect to SQL Server: %w", err)
+	}
+
+	if err := m.DB.Ping(); err != nil {
+		return nil, fmt.Errorf("gob: ping to SQL Server: %w", err)
+	}
+
+	m.DB.SetConnMaxIdleTime(args.ConnIdleTime)
+	m.DB.SetConnMaxLifetime(args.ConnLifeTime)
+	m.DB.SetMaxIdleConns(args.IdleConns)
+	m.DB.SetMaxOpenConns(args.OpenConns)
+	m.observer = newObserver(args, "SQL Server")
+
+	return &m, nil
+}
+
+func (db *mssql) Close() {
+	db.DB.Close()
+}
+
+func (db *mssql) exec(ctx context.Context, sql string) error {
+	if _, err := db.ExecContext(ctx, sql); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on SQL Server: %w", sql, err)
+	}
+
+	return nil
+}
+
+func (db *mssql) Metadata(ctx context.Context, model string) (*Metadata, error) {
+	metadata := &Metadata{Model: model}
+	if err := db.columns(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	if err := db.keys(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (db *mssql) columns(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	rows, err := db.QueryContext(ctx, mssqlColumnsSQL, model)
+	if err != nil {
+		return fmt.Errorf("gob: query columns of model '%s' on SQL Server: %w", model, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var column Column
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &column.Default); err != nil {
+			return fmt.Errorf("gob: scan columns of model '%s' on SQL Server: %w", model, err)
+		}
+
+		column.typ = mssqlColumnType(column.Type)
+		metadata.Columns = append(metadata.Columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("gob: read columns of model '%s' on SQL Server: %w", model, err)
+	}
+
+	if len(metadata.Columns) == 0 {
+		return fmt.Errorf("%w '%s' on SQL Server", ErrModelNotFound, model)
+	}
+
+	return nil
+}
+
+func (db *mssql) keys(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	rows, err := db.QueryContext(ctx, mssqlKeysSQL, model)
+	if err != nil {
+		return fmt.Errorf("gob: query keys of model '%s' on SQL Server: %w", model, err)
+	}
+	defer rows.Close()
+
+	var (
+		indexes []string
+		columns = make(map[string][]string)
+	)
+
+	for rows.Next() {
+		var (
+			index      string
+			primaryKey bool
+			column     string
+		)
+
+		if err := rows.Scan(&index, &primaryKey, &column); err != nil {
+			return fmt.Errorf("gob: scan keys of model '%s' on SQL Server: %w", model, err)
+		}
+
+		if primaryKey {
+			metadata.PrimaryKey = append(metadata.PrimaryKey, column)
+			continue
+		}
+
+		if _, ok := columns[index]; !ok {
+			indexes = append(indexes, index)
+		}
+		columns[index] = append(columns[index], column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("gob: read keys of model '%s' on SQL Server: %w", model, err)
+	}
+
+	for _, index := range indexes {
+		metadata.UniqueKeys = append(metadata.UniqueKeys, columns[index])
+	}
+
+	return nil
+}
+
+// mssqlColumnType maps system type name to column type
+func mssqlColumnType(typ string) columnType {
+	switch strings.ToLower(typ) {
+	case "tinyint", "smallint":
+		// tinyint is unsigned and overflows int8
+		return columnType{kind: kindInt, bits: 16}
+	case "int":
+		return columnType{kind: kindInt, bits: 32}
+	case "bigint":
+		return columnType{kind: kindInt, bits: 64}
+	case "bit":
+		return columnType{kind: kindBool}
+	case "real", "float", "decimal", "numeric", "money", "smallmoney":
+		return columnType{kind: kindFloat}
+	case "char", "varchar", "nchar", "nvarchar", "text", "ntext", "uniqueidentifier":
+		// uniqueidentifier is converted from string by the server
+		return columnType{kind: kindString}
+	case "datetime", "datetime2", "smalldatetime", "datetimeoffset":
+		return columnType{kind: kindTime}
+	case "date":
+		return columnType{kind: kindDate}
+	case "binary", "varbinary", "image":
+		return columnType{kind: kindBytes}
+	}
+
+	return columnType{kind: kindAny}
+}
+
+// mssqlTypeName returns SQL Server type of column type; key columns require types within 900 bytes
+func mssqlTypeName(typ columnType, key bool) string {
+	switch typ.kind {
+	case kindInt:
+		switch typ.bits {
+		case 8, 16:
+			return "SMALLINT"
+		case 32:
+			return "INT"
+		}
+		return "BIGINT"
+	case kindFloat:
+		return "FLOAT"
+	case kindBool:
+		return "BIT"
+	case kindTime:
+		return "DATETIME2"
+	case kindDate:
+		return "DATE"
+	case kindUUID:
+		return "UNIQUEIDENTIFIER"
+	case kindBytes:
+		if key {
+			return "VARBINARY(900)"
+		}
+		return "VARBINARY(MAX)"
+	case kindJSON, kindList, kindMap:
+		return "NVARCHAR(MAX)"
+	}
+
+	if key {
+		return "NVARCHAR(450)"
+	}
+
+	return "NVARCHAR(MAX)"
+}
+
+func (db *mssql) AddColumn(ctx context.Context, model, column string, value interface{}) error {
+	return db.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD %s %s", model, column, mssqlTypeName(inferType(value), false)))
+}
+
+// mssqlCreateModelSQL returns CREATE TABLE statement of schema; SQL Server has no IF NOT EXISTS clause
+func mssqlCreateModelSQL(schema modelSchema) string {
+	var defs []string
+	for _, column := range schema.columns {
+		def := fmt.Sprintf("%s %s", column.name, mssqlTypeName(column.typ, column.key))
+		if column.key {
+			def = def + " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+
+	return fmt.Sprintf("IF OBJECT_ID('%s', 'U') IS NULL CREATE TABLE %s (%s, PRIMARY KEY (%s))",
+		schema.model,
+		schema.model,
+		strings.Join(defs, ", "),
+		strings.Join(schema.keys, ", "),
+	)
+}
+
+func (db *mssql) CreateModel(ctx context.Context, args UpsertArgs, sampleSize int) error {
+	schema, err := inferModelSchema(args, sampleSize)
+	if err != nil {
+		return err
+	}
+
+	return db.exec(ctx, mssqlCreateModelSQL(schema))
+}
+
+// mssqlBatch is the rows of a batch sharing the same columns
+type mssqlBatch struct {
+	columns []string
+	rows    []Row
+}
+
+// mssqlBatches groups rows by columns in order of first row of group; rows with duplicate keys
+// in a group are reduced to the row a sequence of upserts would have left in the model as
+// MERGE rejects source rows matching the same target row
+func mssqlBatches(upsertArgs UpsertArgs) []mssqlBatch {
+	var (
+		batches []mssqlBatch
+		groups  = make(map[string]int)
+		seen    = make(map[string]map[string]int)
+	)
+
+	for _, row := range upsertArgs.Rows {
+		if row.Len() == 0 {
+			continue // ignore empty row
+		}
+
+		columns := row.Columns()
+		group := strings.Join(columns, ",")
+		idx, ok := groups[group]
+		if !ok {
+			idx = len(batches)
+			groups[group] = idx
+			seen[group] = make(map[string]int)
+			batches = append(batches, mssqlBatch{columns: columns})
+		}
+
+		key, ok := encodeKey(row, upsertArgs.Keys)
+		if !ok {
+			batches[idx].rows = append(batches[idx].rows, row)
+			continue
+		}
+
+		rowIdx, dup := seen[group][key]
+		switch {
+		case !dup:
+			seen[group][key] = len(batches[idx].rows)
+			batches[idx].rows = append(batches[idx].rows, row)
+		case upsertArgs.ConflictAction == ConflictActionUpdate:
+			batches[idx].rows[rowIdx] = row
+		}
+	}
+
+	return batches
+}
+
+// mssqlTempModel returns name of temp model rows of model are copied to
+func mssqlTempModel(model string) string {
+	_, table := splitModel(model)
+	return "#gob_" + table
+}
+
+// mssqlTempModelSQL returns statement to create empty temp model with columns of model; UNION ALL keeps
+// SELECT INTO from copying IDENTITY of columns so values of rows are kept
+func mssqlTempModelSQL(model string, columns []string) string {
+	selectList := strings.Join(columns, ",")
+	return fmt.Sprintf("SELECT TOP 0 %s INTO %s FROM %s UNION ALL SELECT %s FROM %s WHERE 1=0",
+		selectList, mssqlTempModel(model), model, selectList, model)
+}
+
+// mssqlIdentityInsertSQL returns statement setting IDENTITY_INSERT of model if an identity column of model is one
+// of columns; rows inserted by MERGE keep values of identity columns
+func mssqlIdentityInsertSQL(model string, columns []string, on bool) string {
+	names := make([]string, len(columns))
+	for idx, column := range columns {
+		names[idx] = mssqlLiteral(column)
+	}
+
+	state := "OFF"
+	if on {
+		state = "ON"
+	}
+
+	return fmt.Sprintf("IF EXISTS (SELECT 1 FROM sys.identity_columns WHERE object_id = OBJECT_ID(%s) AND name IN (%s)) SET IDENTITY_INSERT %s %s",
+		mssqlLiteral(model), strings.Join(names, ","), model, state)
+}
+
+// mssqlLiteral returns s as string literal
+func mssqlLiteral(s string) string {
+	return "N'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// mssqlMergeSQL returns MERGE statement applying rows of temp model to model
+func mssqlMergeSQL(columns []string, upsertArgs UpsertArgs) string {
+	var (
+		on           []string
+		updateClause []string
+		values       []string
+		action       string
+	)
+
+	for _, key := range upsertArgs.Keys {
+		on = append(on, fmt.Sprintf("target.%s=source.%s", key, key))
+	}
+
+	for _, column := range columns {
+		values = append(values, "source."+column)
+		if !upsertArgs.IsKey(column) {
+			updateClause = append(updateClause, fmt.Sprintf("target.%s=source.%s", column, column))
+		}
+	}
+
+	if upsertArgs.ConflictAction == ConflictActionUpdate && len(updateClause) > 0 {
+		action = fmt.Sprintf("WHEN MATCHED THEN UPDATE SET %s ", strings.Join(updateClause, ","))
+	}
+
+	// HOLDLOCK avoids concurrent inserts of the same key between match and insert
+	return fmt.Sprintf("MERGE INTO %s WITH (HOLDLOCK) AS target USING %s AS source ON (%s) %sWHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		upsertArgs.Model,
+		mssqlTempModel(upsertArgs.Model),
+		strings.Join(on, " AND "),
+		action,
+		strings.Join(columns, ","),
+		strings.Join(values, ","),
+	)
+}
+
+// validateMSSQLConflictTarget verifies target of MERGE before starting the transaction
+func validateMSSQLConflictTarget(upsertArgs UpsertArgs) error {
+	if upsertArgs.Constraint != "" {
+		return fmt.Errorf("%w constraint not supported by SQL Server", ErrInvalidConflictTarget)
+	}
+
+	if upsertArgs.KeyPredicate != "" {
+		return fmt.Errorf("%w key predicate not supported by SQL Server", ErrInvalidConflictTarget)
+	}
+
+	if len(upsertArgs.Keys) == 0 {
+		return ErrEmptykeys
+	}
+
+	return nil
+}
+
+func (db *mssql) Upsert(ctx context.Context, upsertArgs UpsertArgs) error {
+	if len(upsertArgs.Rows) == 0 {
+		return nil
+	}
+
+	if err := validateMSSQLConflictTarget(upsertArgs); err != nil {
+		return err
+	}
+
+	// wait for merge statements before transaction to not hold locks
+	batches := mssqlBatches(upsertArgs)
+	if err := db.observer.wait(ctx, len(batches)); err != nil {
+		return err
+	}
+
+	// start transaction
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("gob: begin SQL Server tx: %w", err)
+	}
+
+	for _, batch := range batches {
+		// statements of temp model, bulk copy and merge within statement timeout
+		stmtCtx, done := db.observer.statementContext(ctx)
+		if err := db.merge(stmtCtx, tx, batch, upsertArgs); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return done(fmt.Errorf("%w rollback tx: %v", err, rollbackErr))
+			}
+			return done(err)
+		}
+		done(nil)
+	}
+
+	// commit transaction
+	_, span := db.observer.commit(ctx, upsertArgs.Model, len(upsertArgs.Rows))
+	err = tx.Commit()
+	endSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("gob: commit SQL Server tx: %w", err)
+	}
+
+	return nil
+}
+
+const (
+	mssqlMaxInsertRows = 1000 // rows of VALUES of INSERT
+	mssqlMaxParams     = 2100 // parameters of statement
+)
+
+// mssqlInsertStatements returns INSERT statements copying rows of batch to temp model; rows are split
+// by limits of rows and parameters of SQL Server
+func mssqlInsertStatements(model string, batch mssqlBatch) []Statement {
+	size := mssqlMaxParams / len(batch.columns)
+	if size > mssqlMaxInsertRows {
+		size = mssqlMaxInsertRows
+	}
+
+	var statements []Statement
+	for start := 0; start < len(batch.rows); start += size {
+		end := start + size
+		if end > len(batch.rows) {
+			end = len(batch.rows)
+		}
+
+		var (
+			values []string
+			args   []interface{}
+		)
+
+		for _, row := range batch.rows[start:end] {
+			params := make([]string, len(batch.columns))
+			for idx, column := range batch.columns {
+				args = append(args, row.Value(column))
+				params[idx] = fmt.Sprintf("@p%d", len(args))
+			}
+			values = append(values, "("+strings.Join(params, ",")+")")
+		}
+
+		statements = append(statements, Statement{
+			SQL:  fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", mssqlTempModel(model), strings.Join(batch.columns, ","), strings.Join(values, ",")),
+			Args: args,
+		})
+	}
+
+	return statements
+}
+
+// Build statements of batch; rows are inserted into temp model instead of bulk copied and merged into model
+func (db *mssql) Build(upsertArgs UpsertArgs) ([]Statement, error) {
+	if err := validateMSSQLConflictTarget(upsertArgs); err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	for _, batch := range mssqlBatches(upsertArgs) {
+		statements = append(statements, Statement{SQL: mssqlTempModelSQL(upsertArgs.Model, batch.columns)})
+		statements = append(statements, mssqlInsertStatements(upsertArgs.Model, batch)...)
+		statements = append(statements,
+			Statement{SQL: mssqlIdentityInsertSQL(upsertArgs.Model, batch.columns, true)},
+			Statement{SQL: mssqlMergeSQL(batch.columns, upsertArgs)},
+			Statement{SQL: mssqlIdentityInsertSQL(upsertArgs.Model, batch.columns, false)},
+			Statement{SQL: "DROP TABLE " + mssqlTempModel(upsertArgs.Model)},
+		)
+	}
+
+	return statements, nil
+}
+
+// merge bulk copies rows of batch to temp model and merges them into model
+func (db *mssql) merge(ctx context.Context, tx *sql.Tx, batch mssqlBatch, upsertArgs UpsertArgs) error {
+	tempModel := mssqlTempModel(upsertArgs.Model)
+	tempSQL := mssqlTempModelSQL(upsertArgs.Model, batch.columns)
+	if _, err := tx.ExecContext(ctx, tempSQL); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on SQL Server: %w", tempSQL, err)
+	}
+
+	// keep NULL values of rows instead of column defaults
+	stmt, err := tx.PrepareContext(ctx, mssqlCopyIn(tempModel, batch.columns...))
+	if err != nil {
+		return fmt.Errorf("gob: prepare bulk copy to '%s' on SQL Server: %w", tempModel, err)
+	}
+	defer stmt.Close()
+
+	for _, row := range batch.rows {
+		var args []interface{}
+		for _, column := range batch.columns {
+			args = append(args, row.Value(column))
+		}
+
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("gob: bulk copy to '%s' on SQL Server: %w", tempModel, err)
+		}
+	}
+
+	// flush rows
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("gob: bulk copy to '%s' on SQL Server: %w", tempModel, err)
+	}
+
+	identitySQL := mssqlIdentityInsertSQL(upsertArgs.Model, batch.columns, true)
+	if _, err := tx.ExecContext(ctx, identitySQL); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on SQL Server: %w", identitySQL, err)
+	}
+
+	mergeSQL := mssqlMergeSQL(batch.columns, upsertArgs)
+	t0 := time.Now()
+	_, err = tx.ExecContext(ctx, mergeSQL)
+	db.observer.statement(ctx, mergeSQL, t0)
+	if err != nil {
+		return fmt.Errorf("gob: execute upsert sql '%s' on SQL Server: %w", mergeSQL, err)
+	}
+
+	identitySQL = mssqlIdentityInsertSQL(upsertArgs.Model, batch.columns, false)
+	if _, err := tx.ExecContext(ctx, identitySQL); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on SQL Server: %w", identitySQL, err)
+	}
+
+	dropSQL := "DROP TABLE " + tempModel
+	if _, err := tx.ExecContext(ctx, dropSQL); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on SQL Server: %w", dropSQL, err)
+	}
+
+	return nil
+}
+
+func (db *mssql) Ping(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("gob: ping SQL Server: %w", err)
+	}
+
+	return nil
+}
+
+// requiresConflictTarget of MERGE
+func (db *mssql) requiresConflictTarget() bool {
+	return true
+}
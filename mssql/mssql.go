@@ -0,0 +1,15 @@
+// Package mssql registers gob.DBProviderMSSQL with the driver github.com/denisenkom/go-mssqldb; import it for its
+// side effect
+//
+//	import _ "github.com/csmadhu/gob/mssql"
+package mssql
+
+import (
+	_ "github.com/csmadhu/gob" // sets hooks
+	"github.com/csmadhu/gob/internal/driver/mssql"
+	"github.com/csmadhu/gob/internal/hooks"
+)
+
+func init() {
+	hooks.RegisterMSSQL(mssql.CopyIn, mssql.RowError)
+}
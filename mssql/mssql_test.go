@@ -0,0 +1,17 @@
+package mssql
+
+import (
+	"testing"
+
+	"github.com/csmadhu/gob"
+)
+
+func TestRegister(t *testing.T) {
+	for _, name := range gob.Providers() {
+		if name == gob.DBProviderMSSQL {
+			return
+		}
+	}
+
+	t.Fatalf("provider %s not registered", gob.DBProviderMSSQL)
+}
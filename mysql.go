@@ -3,112 +3,386 @@ package gob
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	// import mysql driver
-	_ "github.com/go-sql-driver/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
 )
 
+// mysqlColumnsSQL lists columns of table in schema or current database in table order
+const mysqlColumnsSQL = `SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE = 'YES', COLUMN_DEFAULT IS NOT NULL OR EXTRA LIKE '%auto_increment%'
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`
+
+// mysqlKeysSQL lists columns of unique indexes of table in schema or current database
+const mysqlKeysSQL = `SELECT INDEX_NAME, COLUMN_NAME
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND NON_UNIQUE = 0
+ORDER BY INDEX_NAME = 'PRIMARY' DESC, INDEX_NAME, SEQ_IN_INDEX`
+
 type mysql struct {
-	*sql.DB
+	*sqlDB
 }
 
-func newMySQL(args connArgs) (db, error) {
-	var (
-		m   mysql
-		err error
-	)
+func init() {
+	Register(DBProviderMySQL, newMySQL)
+	Register(DBProviderMariaDB, newMariaDB)
+	Register(DBProviderTiDB, newTiDB)
+}
+
+// mysqlDialect renders INSERT ... ON DUPLICATE KEY UPDATE statements of MySQL compatible databases
+type mysqlDialect struct {
+	name     string // name of database in errors
+	rowAlias bool   // refer to inserted rows by row alias instead of VALUES() deprecated by MySQL 8.0.20
+}
+
+var (
+	// DialectMySQL renders statements of MySQL server
+	DialectMySQL Dialect = mysqlDialect{name: "MySQL server"}
+
+	// DialectMariaDB renders statements of MariaDB server
+	DialectMariaDB Dialect = mysqlDialect{name: "MariaDB server"}
+
+	// DialectTiDB renders statements of TiDB
+	DialectTiDB Dialect = mysqlDialect{name: "TiDB"}
+)
+
+func newMySQL(args ConnArgs) (Provider, error) {
+	return newMySQLDialect(args, DialectMySQL)
+}
 
-	m.DB, err = sql.Open("mysql", args.connStr)
+func newMariaDB(args ConnArgs) (Provider, error) {
+	return newMySQLDialect(args, DialectMariaDB)
+}
+
+func newTiDB(args ConnArgs) (Provider, error) {
+	return newMySQLDialect(args, DialectTiDB)
+}
+
+func newMySQLDialect(args ConnArgs, dialect Dialect) (Provider, error) {
+	db, err := newSQLDB(args, dialect, dialect.(mysqlDialect).name)
 	if err != nil {
-		return nil, fmt.Errorf("gob: connect to MySQL: %w", err)
+		return nil, err
 	}
 
-	if err := m.DB.Ping(); err != nil {
-		return nil, fmt.Errorf("gob: ping to MySQL: %w", err)
+	var version string
+	if err := db.QueryRow("SELECT VERSION()").Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("gob: query version of %s: %w", db.name, err)
 	}
 
-	m.DB.SetConnMaxIdleTime(args.connIdleTime)
-	m.DB.SetConnMaxLifetime(args.connLifeTime)
-	m.DB.SetMaxIdleConns(args.idleConns)
-	m.DB.SetMaxOpenConns(args.openConns)
+	if dialect == DialectMySQL && mysqlRowAlias(version) {
+		db.dialect = mysqlDialect{name: "MySQL server", rowAlias: true}
+	}
 
-	return &m, nil
+	return &mysql{db}, nil
 }
 
-func (db *mysql) close() {
-	db.Close()
+// mysqlRowAlias reports whether MySQL server of version supports row alias of INSERT; MariaDB and TiDB report
+// versions of MySQL they are compatible with but do not
+func mysqlRowAlias(version string) bool {
+	if strings.Contains(version, "MariaDB") || strings.Contains(version, "TiDB") {
+		return false
+	}
+
+	var major, minor, patch int
+	if n, _ := fmt.Sscanf(version, "%d.%d.%d", &major, &minor, &patch); n < 2 {
+		return false
+	}
+
+	switch {
+	case major != 8:
+		return major > 8
+	case minor != 0:
+		return minor > 0
+	default:
+		return patch >= 20
+	}
 }
 
-func (db *mysql) upsert(ctx context.Context, upsertArgs UpsertArgs) error {
-	// start transaction
-	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
-	if err != nil {
-		return fmt.Errorf("gob: begin MySQL tx: %w", err)
+func (mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (mysqlDialect) Quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// MaxArgs of prepared statement
+func (mysqlDialect) MaxArgs() int {
+	return 65535
+}
+
+// UpsertSQL updates all columns of rows on duplicate of any unique key; keys are not part of the statement.
+// Providers connected to MySQL 8.0.20 or later refer to inserted rows by row alias; DialectMySQL uses VALUES()
+// as version of server is not known without connection
+func (d mysqlDialect) UpsertSQL(args UpsertArgs, columns []string, values []string) (string, error) {
+	quoted := quoteColumns(d, columns)
+	if args.ConflictAction == ConflictActionNothing {
+		return fmt.Sprintf("INSERT IGNORE INTO %s(%s) VALUES%s",
+			args.Model,
+			strings.Join(quoted, ","),
+			strings.Join(values, ","),
+		), nil
 	}
 
-	for _, row := range upsertArgs.Rows {
-		if row.Len() == 0 {
-			continue // ignore empty row
+	var (
+		alias        string
+		updateClause []string
+	)
+
+	for _, column := range quoted {
+		if d.rowAlias {
+			updateClause = append(updateClause, fmt.Sprintf("%s=new.%s", column, column))
+		} else {
+			updateClause = append(updateClause, fmt.Sprintf("%s=VALUES(%s)", column, column))
 		}
+	}
+
+	if d.rowAlias {
+		alias = " AS new"
+	}
+
+	return fmt.Sprintf("INSERT INTO %s(%s) VALUES%s%s ON DUPLICATE KEY UPDATE %s",
+		args.Model,
+		strings.Join(quoted, ","),
+		strings.Join(values, ","),
+		alias,
+		strings.Join(updateClause, ","),
+	), nil
+}
+
+func (db *mysql) Metadata(ctx context.Context, model string) (*Metadata, error) {
+	metadata := &Metadata{Model: model}
+	if err := db.columns(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	if err := db.keys(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (db *mysql) columns(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	schema, table := splitModel(model)
+	rows, err := db.QueryContext(ctx, mysqlColumnsSQL, schema, table)
+	if err != nil {
+		return fmt.Errorf("gob: query columns of model '%s' on MySQL server: %w", model, err)
+	}
+	defer rows.Close()
 
-		sql, args := db.rowToSQL(row, upsertArgs)
-		if _, err := tx.ExecContext(ctx, sql, args...); err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				return fmt.Errorf("gob: execute upsert sql '%s' on MySQL server: %v rollback tx: %w", sql, err, rollbackErr)
-			}
-			return fmt.Errorf("gob: execute upsert sql '%s' on MySQL server: %w", sql, err)
+	for rows.Next() {
+		var column Column
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &column.Default); err != nil {
+			return fmt.Errorf("gob: scan columns of model '%s' on MySQL server: %w", model, err)
 		}
+
+		column.typ = mysqlColumnType(column.Type)
+		metadata.Columns = append(metadata.Columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("gob: read columns of model '%s' on MySQL server: %w", model, err)
 	}
 
-	// commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("gob: commit MySQL tx: %w", err)
+	if len(metadata.Columns) == 0 {
+		return fmt.Errorf("%w '%s' on MySQL server", ErrModelNotFound, model)
 	}
 
 	return nil
 }
 
-func (db *mysql) rowToSQL(row Row, upsertArgs UpsertArgs) (sql string, args []interface{}) {
-	upsertSQL := "INSERT %s INTO %s(%s) VALUES(%s) %s"
+// mysqlColumnType maps COLUMN_TYPE to column type
+func mysqlColumnType(typ string) columnType {
+	typ = strings.ToLower(typ)
+	if typ == "tinyint(1)" {
+		return columnType{kind: kindBool}
+	}
+
+	// remove type modifiers e.g. varchar(255), bigint unsigned
+	if idx := strings.IndexAny(typ, "( "); idx >= 0 {
+		typ = typ[:idx]
+	}
+
+	switch typ {
+	case "tinyint":
+		return columnType{kind: kindInt, bits: 8}
+	case "smallint":
+		return columnType{kind: kindInt, bits: 16}
+	case "mediumint", "int", "integer":
+		return columnType{kind: kindInt, bits: 32}
+	case "bigint":
+		return columnType{kind: kindInt, bits: 64}
+	case "float", "double", "decimal":
+		return columnType{kind: kindFloat}
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return columnType{kind: kindString}
+	case "datetime", "timestamp":
+		return columnType{kind: kindTime}
+	case "date":
+		return columnType{kind: kindDate}
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return columnType{kind: kindBytes}
+	case "json":
+		return columnType{kind: kindJSON}
+	}
+
+	return columnType{kind: kindAny}
+}
+
+// mysqlTypeName returns MySQL type of column type; key columns require types of bounded length
+func mysqlTypeName(typ columnType, key bool) string {
+	switch typ.kind {
+	case kindInt:
+		switch typ.bits {
+		case 8:
+			return "TINYINT"
+		case 16:
+			return "SMALLINT"
+		case 32:
+			return "INT"
+		}
+		return "BIGINT"
+	case kindFloat:
+		return "DOUBLE"
+	case kindBool:
+		return "BOOLEAN"
+	case kindTime:
+		return "DATETIME(6)"
+	case kindDate:
+		return "DATE"
+	case kindUUID:
+		return "CHAR(36)"
+	case kindBytes:
+		if key {
+			return "VARBINARY(255)"
+		}
+		return "BLOB"
+	case kindJSON, kindList, kindMap:
+		return "JSON"
+	}
+
+	if key {
+		return "VARCHAR(255)"
+	}
+
+	return "TEXT"
+}
+
+func (db *mysql) AddColumn(ctx context.Context, model, column string, value interface{}) error {
+	return db.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", model, column, mysqlTypeName(inferType(value), false)))
+}
+
+// mysqlCreateModelSQL returns CREATE TABLE statement of schema
+func mysqlCreateModelSQL(schema modelSchema) string {
+	var defs []string
+	for _, column := range schema.columns {
+		def := fmt.Sprintf("%s %s", column.name, mysqlTypeName(column.typ, column.key))
+		if column.key {
+			def = def + " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s))",
+		schema.model,
+		strings.Join(defs, ", "),
+		strings.Join(schema.keys, ", "),
+	)
+}
+
+func (db *mysql) CreateModel(ctx context.Context, args UpsertArgs, sampleSize int) error {
+	schema, err := inferModelSchema(args, sampleSize)
+	if err != nil {
+		return err
+	}
+
+	return db.exec(ctx, mysqlCreateModelSQL(schema))
+}
+
+func (db *mysql) keys(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	schema, table := splitModel(model)
+	rows, err := db.QueryContext(ctx, mysqlKeysSQL, schema, table)
+	if err != nil {
+		return fmt.Errorf("gob: query keys of model '%s' on MySQL server: %w", model, err)
+	}
+	defer rows.Close()
 
 	var (
-		cols         []string
-		values       []string
-		updateClause []string
-		updateArgs   []interface{}
-		ignoreAction string
-		updateAction string
+		indexes []string
+		columns = make(map[string][]string)
+		skip    = make(map[string]bool)
 	)
 
-	for _, column := range row.Columns() {
-		cols = append(cols, column)
-		values = append(values, "?")
-		args = append(args, row.Value(column))
+	for rows.Next() {
+		var (
+			index  string
+			column sql.NullString
+		)
+
+		if err := rows.Scan(&index, &column); err != nil {
+			return fmt.Errorf("gob: scan keys of model '%s' on MySQL server: %w", model, err)
+		}
+
+		// functional key parts have no column
+		if !column.Valid {
+			skip[index] = true
+			continue
+		}
 
-		updateClause = append(updateClause, fmt.Sprintf("%s=?", column))
-		updateArgs = append(updateArgs, row.Value(column))
+		if index == "PRIMARY" {
+			metadata.PrimaryKey = append(metadata.PrimaryKey, column.String)
+			continue
+		}
+
+		if _, ok := columns[index]; !ok {
+			indexes = append(indexes, index)
+		}
+		columns[index] = append(columns[index], column.String)
 	}
 
-	switch upsertArgs.ConflictAction {
-	case ConflictActionUpdate:
-		updateAction = fmt.Sprintf("ON DUPLICATE KEY UPDATE %s", strings.Join(updateClause, ","))
-		args = append(args, updateArgs...)
-	case ConflictActionNothing:
-		ignoreAction = "IGNORE"
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("gob: read keys of model '%s' on MySQL server: %w", model, err)
 	}
 
-	sql = fmt.Sprintf(upsertSQL,
-		ignoreAction,
-		upsertArgs.Model,
-		strings.Join(cols, ","),
-		strings.Join(values, ","),
-		updateAction,
-	)
+	for _, index := range indexes {
+		if !skip[index] {
+			metadata.UniqueKeys = append(metadata.UniqueKeys, columns[index])
+		}
+	}
+
+	return nil
+}
+
+// mysqlRowErrors are numbers of errors caused by values of a row e.g. 1062 duplicate entry of unique key
+var mysqlRowErrors = map[uint16]bool{
+	1048: true, // column cannot be null
+	1062: true, // duplicate entry
+	1264: true, // out of range value
+	1265: true, // data truncated
+	1292: true, // incorrect value
+	1364: true, // field doesn't have a default value
+	1366: true, // incorrect value for column
+	1406: true, // data too long
+	1451: true, // foreign key of child row
+	1452: true, // foreign key of parent row
+	3819: true, // check constraint violated
+	4025: true, // constraint failed
+}
 
-	// remove white space
-	sql = strings.TrimSpace(sql)
-	sql = strings.Join(strings.Fields(sql), " ")
-	return sql, args
+// mysqlRowError reports whether err is caused by values of a row
+func mysqlRowError(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlRowErrors[mysqlErr.Number]
 }
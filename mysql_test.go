@@ -1,9 +1,10 @@
 package gob
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"reflect"
 	"testing"
 	"time"
@@ -12,27 +13,25 @@ import (
 var (
 	testMySQLDB      *sql.DB
 	testMySQLConnStr = "gob:fY5SGU=t@(localhost:3306)/gob"
-	testMySQLArgs    = connArgs{
-		connStr:      testMySQLConnStr,
-		idleConns:    1,
-		openConns:    1,
-		connIdleTime: 3 * time.Second,
-		connLifeTime: 3 * time.Second,
+	testMySQLArgs    = ConnArgs{
+		ConnStr:      testMySQLConnStr,
+		IdleConns:    1,
+		OpenConns:    1,
+		ConnIdleTime: 3 * time.Second,
+		ConnLifeTime: 3 * time.Second,
 	}
 )
 
-func init() {
-	if err := setupMySQLDB(); err != nil {
-		log.Fatalf("setup mysql; err: %v", err)
-	}
-}
-
 func setupMySQLDB() error {
 	mysqlDB, err := sql.Open("mysql", testMySQLConnStr)
 	if err != nil {
 		return fmt.Errorf("connect to MySQL: %w", err)
 	}
 
+	if err := mysqlDB.Ping(); err != nil {
+		return fmt.Errorf("%w: connect to MySQL: %v", errTestUnreachable, err)
+	}
+
 	if _, err := mysqlDB.Exec("DROP TABLE IF EXISTS students"); err != nil {
 		return fmt.Errorf("drop table: %w", err)
 	}
@@ -53,23 +52,6 @@ func setupMySQLDB() error {
 	return nil
 }
 
-func testGenStudentRowsMySQL(count int) (rows []Row) {
-	subjects := `["english", "calculus"]`
-	profile := `{"state": "state-%d", "street": "street-%d", "zipcode": %d}`
-	for i := 0; i < count; i++ {
-		row := NewRow()
-		row.Add("name", fmt.Sprintf("name-%d", i))
-		row.Add("age", i)
-		row.Add("profile", fmt.Sprintf(profile, i, i, i))
-		row.Add("subjects", subjects)
-		row.Add("birthday", time.Now())
-
-		rows = append(rows, row)
-	}
-
-	return rows
-}
-
 func testVerifyStudentRowsMySQL(t *testing.T, rows []Row) {
 	for _, want := range rows {
 		dbRows, err := testMySQLDB.Query("SELECT name, age, profile, subjects FROM students WHERE name=?", want.Value("name"))
@@ -113,36 +95,107 @@ func testVerifyStudentRowsMySQL(t *testing.T, rows []Row) {
 }
 
 func TestNewMySQL(t *testing.T) {
+	testSetup(t, setupMySQLDB)
 	db, err := newMySQL(testMySQLArgs)
 	if err != nil {
 		t.Fatalf("init MySQL server; err: %v", err)
 	}
 
-	db.close()
+	db.Close()
 }
 
 func TestUpsertMySQL(t *testing.T) {
-	setupMySQLDB()
+	testSetup(t, setupMySQLDB)
 	db, err := newMySQL(testMySQLArgs)
 	if err != nil {
 		t.Fatalf("init PostgreSQL server err: %v", err)
 	}
-	defer db.close()
+	defer db.Close()
 
 	testUpsertDB(t, db, testGenStudentRowsMySQL, testVerifyStudentRowsMySQL)
 }
 
 func TestRowToSQLMySQL(t *testing.T) {
 	wantSQLs := []string{
-		"INSERT INTO students(age,birthday,name,profile,subjects) VALUES(?,?,?,?,?) ON DUPLICATE KEY UPDATE age=?,birthday=?,name=?,profile=?,subjects=?",
-		"INSERT IGNORE INTO students(age,birthday,name,profile,subjects) VALUES(?,?,?,?,?)",
+		"INSERT INTO students(`age`,`birthday`,`name`,`profile`,`subjects`) VALUES(?,?,?,?,?) ON DUPLICATE KEY UPDATE `age`=VALUES(`age`),`birthday`=VALUES(`birthday`),`name`=VALUES(`name`),`profile`=VALUES(`profile`),`subjects`=VALUES(`subjects`)",
+		"INSERT IGNORE INTO students(`age`,`birthday`,`name`,`profile`,`subjects`) VALUES(?,?,?,?,?)",
 	}
 
 	wantArgs := [][]interface{}{
-		{0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`, 0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`},
+		{0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`},
 		{0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`},
 	}
 
-	m := &mysql{}
-	testRowToSQL(t, testGenStudentRowsMySQL, m.rowToSQL, wantSQLs, wantArgs)
+	testRowToSQL(t, testGenStudentRowsMySQL, testDialectRowToSQL(DialectMySQL), wantSQLs, wantArgs)
+
+	t.Run("rowAlias", func(t *testing.T) {
+		wantSQLs := []string{
+			"INSERT INTO students(`age`,`birthday`,`name`,`profile`,`subjects`) VALUES(?,?,?,?,?) AS new ON DUPLICATE KEY UPDATE `age`=new.`age`,`birthday`=new.`birthday`,`name`=new.`name`,`profile`=new.`profile`,`subjects`=new.`subjects`",
+			"INSERT IGNORE INTO students(`age`,`birthday`,`name`,`profile`,`subjects`) VALUES(?,?,?,?,?)",
+		}
+
+		wantArgs := [][]interface{}{
+			{0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`},
+			{0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`},
+		}
+
+		testRowToSQL(t, testGenStudentRowsMySQL, testDialectRowToSQL(mysqlDialect{name: "MySQL server", rowAlias: true}), wantSQLs, wantArgs)
+	})
+}
+
+func TestMySQLRowAlias(t *testing.T) {
+	for _, test := range []struct {
+		version string
+		want    bool
+	}{
+		{"5.7.31", false},
+		{"8.0.19", false},
+		{"8.0.20", true},
+		{"8.0.36-0ubuntu0.22.04.1", true},
+		{"8.4.0", true},
+		{"9.0.1", true},
+		{"10.6.16-MariaDB-1:10.6.16+maria~ubu2004", false},
+		{"8.0.11-TiDB-v7.5.0", false},
+		{"unknown", false},
+	} {
+		if got := mysqlRowAlias(test.version); got != test.want {
+			t.Fatalf("row alias of version %s got: %v want: %v", test.version, got, test.want)
+		}
+	}
+}
+
+func TestMetadataMySQL(t *testing.T) {
+	testSetup(t, setupMySQLDB)
+	db, err := newMySQL(testMySQLArgs)
+	if err != nil {
+		t.Fatalf("init MySQL err: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.(MetadataProvider).Metadata(context.Background(), "teachers"); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("metadata of unknown model got: %v want: %v", err, ErrModelNotFound)
+	}
+
+	got, err := db.(MetadataProvider).Metadata(context.Background(), "students")
+	if err != nil {
+		t.Fatalf("metadata err: %v", err)
+	}
+
+	// SERIAL implies unique index on id
+	want := &Metadata{
+		Model: "students",
+		Columns: []Column{
+			{Name: "id", Type: "bigint unsigned", Default: true, typ: columnType{kind: kindInt, bits: 64}},
+			{Name: "name", Type: "varchar(255)", typ: columnType{kind: kindString}},
+			{Name: "age", Type: "int", Nullable: true, typ: columnType{kind: kindInt, bits: 32}},
+			{Name: "profile", Type: "json", Nullable: true, typ: columnType{kind: kindJSON}},
+			{Name: "subjects", Type: "json", Nullable: true, typ: columnType{kind: kindJSON}},
+			{Name: "birthday", Type: "timestamp", Nullable: true, typ: columnType{kind: kindTime}},
+		},
+		PrimaryKey: []string{"id"},
+		UniqueKeys: [][]string{{"id"}, {"name"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("metadata got: %+v want: %+v", got, want)
+	}
 }
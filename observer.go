@@ -0,0 +1,113 @@
+package gob
+
+import (
+	"context"
+	"time"
+)
+
+// logMaxSQL is the maximum length of statements in events
+const logMaxSQL = 1024
+
+// logSQL truncates statement of event
+func logSQL(sql string) string {
+	if len(sql) <= logMaxSQL {
+		return sql
+	}
+
+	return sql[:logMaxSQL] + "..."
+}
+
+// observer reports statements and transactions executed by providers to logger, metrics and tracer
+type observer struct {
+	logger        Logger
+	metrics       Metrics
+	tracer        Tracer
+	slowStatement time.Duration // log statements taking longer, 0 to disable
+	provider      string        // name of database in events
+	dbProvider    DBProvider    // provider in metrics
+	limit         *rateLimiter  // limits statements per second
+	timeout       time.Duration // of statements, 0 for no timeout
+}
+
+func newObserver(args ConnArgs, provider string) observer {
+	o := observer{
+		logger:        args.Logger,
+		metrics:       args.Metrics,
+		tracer:        args.Tracer,
+		slowStatement: args.SlowStatement,
+		provider:      provider,
+		dbProvider:    args.DBProvider,
+		limit:         newRateLimiter(args.StatementsPerSecond),
+		timeout:       args.StatementTimeout,
+	}
+
+	if o.logger == nil {
+		o.logger = nopLogger{}
+	}
+
+	if o.metrics == nil {
+		o.metrics = nopMetrics{}
+	}
+
+	if o.tracer == nil {
+		o.tracer = nopTracer{}
+	}
+
+	return o
+}
+
+// wait for turn of n statements within rate limit
+func (o observer) wait(ctx context.Context, n int) error {
+	return o.limit.wait(ctx, n)
+}
+
+// statementContext returns ctx of statement canceled after statement timeout and func ending it; the func
+// marks err of statement timed out as ErrTimeout. Errors of ctx done are marked by Gob
+func (o observer) statementContext(ctx context.Context) (context.Context, func(err error) error) {
+	stmtCtx, cancel := withTimeout(ctx, o.timeout)
+	return stmtCtx, func(err error) error {
+		defer cancel()
+		if ctx.Err() != nil {
+			return err
+		}
+
+		return ctxError(stmtCtx, "statement", err)
+	}
+}
+
+// statement logs statement executed since start if slower than threshold
+func (o observer) statement(ctx context.Context, sql string, start time.Time) {
+	if o.slowStatement <= 0 {
+		return
+	}
+
+	if elapsed := time.Since(start); elapsed >= o.slowStatement {
+		o.logger.Log(ctx, LevelWarn, "slow statement",
+			Field{"provider", o.provider},
+			Field{"sql", logSQL(sql)},
+			Field{"duration", elapsed},
+			Field{"threshold", o.slowStatement},
+		)
+	}
+}
+
+// retry logs and counts retry of transaction of model failed with err after backoff
+func (o observer) retry(ctx context.Context, model string, attempt int, backoff time.Duration, err error) {
+	o.metrics.Retry(o.dbProvider, model)
+	o.logger.Log(ctx, LevelWarn, "retry transaction",
+		Field{"provider", o.provider},
+		Field{"model", model},
+		Field{"attempt", attempt},
+		Field{"backoff", backoff},
+		Field{"error", err},
+	)
+}
+
+// commit starts span of commit of transaction of rows of model
+func (o observer) commit(ctx context.Context, model string, rows int) (context.Context, Span) {
+	return o.tracer.Start(ctx, SpanCommit,
+		Field{"model", model},
+		Field{"rows", rows},
+		Field{"provider", string(o.dbProvider)},
+	)
+}
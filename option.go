@@ -2,6 +2,7 @@ package gob
 
 import (
 	"fmt"
+	"io"
 	"time"
 )
 
@@ -73,3 +74,229 @@ func WithOpenConns(n int) Option {
 		return nil
 	}
 }
+
+// WithValidation validates and coerces rows against columns of model before upserting each batch
+func WithValidation(validate bool) Option {
+	return func(gob *Gob) error {
+		gob.setValidate(validate)
+		return nil
+	}
+}
+
+// WithUnknownColumnPolicy sets handling of row columns not found in model
+func WithUnknownColumnPolicy(model string, policy UnknownColumnPolicy) Option {
+	return func(gob *Gob) error {
+		if model == "" {
+			return ErrEmptyModel
+		}
+
+		switch policy {
+		case UnknownColumnError, UnknownColumnDrop, UnknownColumnAdd:
+		default:
+			return fmt.Errorf("gob: invalid unknownColumnPolicy: %s", policy)
+		}
+
+		gob.setUnknownColumnPolicy(model, policy)
+		return nil
+	}
+}
+
+// WithCreateModel creates model not found in database with column types inferred from
+// first sampleSize rows and primary key of UpsertArgs.Keys
+func WithCreateModel(sampleSize int) Option {
+	return func(gob *Gob) error {
+		if sampleSize <= 0 {
+			return fmt.Errorf("gob: invalid sampleSize: %d", sampleSize)
+		}
+
+		gob.setCreateModel(sampleSize)
+		return nil
+	}
+}
+
+// WithMapping sets mapping of source fields to columns of model applied to rows before upsert
+func WithMapping(model string, mapping Mapping) Option {
+	return func(gob *Gob) error {
+		if model == "" {
+			return ErrEmptyModel
+		}
+
+		if err := mapping.validate(); err != nil {
+			return err
+		}
+
+		gob.setMapping(model, mapping)
+		return nil
+	}
+}
+
+// WithMappings sets mappings of models e.g. loaded from config with LoadMappings
+func WithMappings(mappings map[string]Mapping) Option {
+	return func(gob *Gob) error {
+		for model, mapping := range mappings {
+			if err := WithMapping(model, mapping)(gob); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// WithDryRun writes statements of each batch with arguments to w instead of executing them; New does not connect
+// to database. Arguments are written as SQL literals or as their Go types if redact is set
+func WithDryRun(w io.Writer, redact bool) Option {
+	return func(gob *Gob) error {
+		if w == nil {
+			return fmt.Errorf("gob: invalid dry run writer: %v", w)
+		}
+
+		gob.setDryRun(w, redact)
+		return nil
+	}
+}
+
+// WithLogger sets logger receiving structured events of batches, retries and slow statements;
+// events are discarded by default
+func WithLogger(logger Logger) Option {
+	return func(gob *Gob) error {
+		if logger == nil {
+			return fmt.Errorf("gob: invalid logger: %v", logger)
+		}
+
+		gob.setLogger(logger)
+		return nil
+	}
+}
+
+// WithMetrics sets metrics receiving rows, latency and errors of batches, retries and stats of connection pool;
+// measurements are discarded by default. NewPrometheusCollector returns Metrics exposed over HTTP
+func WithMetrics(metrics Metrics) Option {
+	return func(gob *Gob) error {
+		if metrics == nil {
+			return fmt.Errorf("gob: invalid metrics: %v", metrics)
+		}
+
+		gob.setMetrics(metrics)
+		return nil
+	}
+}
+
+// WithTracer sets tracer starting spans of each upsert, batch and commit with model, rows, provider and error;
+// spans are discarded by default
+func WithTracer(tracer Tracer) Option {
+	return func(gob *Gob) error {
+		if tracer == nil {
+			return fmt.Errorf("gob: invalid tracer: %v", tracer)
+		}
+
+		gob.setTracer(tracer)
+		return nil
+	}
+}
+
+// WithProgress calls fn after each committed batch of Upsert with cumulative rows, batches, elapsed
+// and estimated remaining time
+func WithProgress(fn ProgressFunc) Option {
+	return func(gob *Gob) error {
+		if fn == nil {
+			return fmt.Errorf("gob: invalid progress func: %v", fn)
+		}
+
+		gob.setProgress(fn)
+		return nil
+	}
+}
+
+// WithCheckpoint records a checkpoint in store after each committed batch of UpsertArgs.Load to Resume the load
+func WithCheckpoint(store CheckpointStore) Option {
+	return func(gob *Gob) error {
+		if store == nil {
+			return fmt.Errorf("gob: invalid checkpoint store: %v", store)
+		}
+
+		gob.setCheckpoints(store)
+		return nil
+	}
+}
+
+// WithDeadLetter writes rows rejected by validation, mapping, unknown column policy or database to dl instead of
+// failing Upsert; rows of a batch failed by database are upserted one by one to find rejected rows
+func WithDeadLetter(dl DeadLetter) Option {
+	return func(gob *Gob) error {
+		if dl == nil {
+			return fmt.Errorf("gob: invalid dead letter: %v", dl)
+		}
+
+		gob.setDeadLetter(dl)
+		return nil
+	}
+}
+
+// WithRateLimit caps rows upserted and statements executed per second by Gob; 0 for no limit.
+// Batches wait for their rows and statements wait for their turn
+func WithRateLimit(rowsPerSecond, statementsPerSecond float64) Option {
+	return func(gob *Gob) error {
+		if rowsPerSecond < 0 || statementsPerSecond < 0 {
+			return fmt.Errorf("gob: invalid rate limit: %v rows/s, %v statements/s", rowsPerSecond, statementsPerSecond)
+		}
+
+		gob.setRateLimit(rowsPerSecond, statementsPerSecond)
+		return nil
+	}
+}
+
+// WithAdaptiveBatchSize adjusts size of batches between min and max to keep latency of batches near target
+// starting at batchSize; size is halved after a failed batch
+func WithAdaptiveBatchSize(target time.Duration, min, max int) Option {
+	return func(gob *Gob) error {
+		if target <= 0 {
+			return fmt.Errorf("gob: invalid target latency: %v", target)
+		}
+
+		if min <= 0 || max < min {
+			return fmt.Errorf("gob: invalid adaptive batch size: %d..%d", min, max)
+		}
+
+		gob.setAdaptiveBatchSize(target, min, max)
+		return nil
+	}
+}
+
+// WithTimeouts of each batch and each statement of batches; 0 for no timeout. Upserts exceeding timeouts
+// or deadline of context fail with ErrTimeout, upserts of canceled context fail with ErrCanceled
+func WithTimeouts(batch, statement time.Duration) Option {
+	return func(gob *Gob) error {
+		if batch < 0 || statement < 0 {
+			return fmt.Errorf("gob: invalid timeouts: batch %v, statement %v", batch, statement)
+		}
+
+		gob.setTimeouts(batch, statement)
+		return nil
+	}
+}
+
+// WithCircuitBreaker fails upserts fast with ErrCircuitOpen after failures consecutive upserts failed to connect.
+// After cooldown the next upsert probes the database, closing the circuit if it is reachable
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(gob *Gob) error {
+		if failures <= 0 || cooldown <= 0 {
+			return fmt.Errorf("gob: invalid circuit breaker: %d failures, cooldown %v", failures, cooldown)
+		}
+
+		gob.setCircuitBreaker(failures, cooldown)
+		return nil
+	}
+}
+
+// WithSlowStatement logs statements taking longer than threshold at warn level; 0 disables
+func WithSlowStatement(threshold time.Duration) Option {
+	return func(gob *Gob) error {
+		if threshold < 0 {
+			return fmt.Errorf("gob: invalid slow statement threshold: %v", threshold)
+		}
+
+		gob.setSlowStatement(threshold)
+		return nil
+	}
+}
@@ -0,0 +1,122 @@
+package gob
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/csmadhu/gob/internal/hooks"
+)
+
+// parquetEncoder writes rows of a batch as a row group; all columns are optional
+type parquetEncoder struct {
+	w       hooks.ParquetWriter
+	columns []fileColumn
+}
+
+// newParquetWriter returns writer of parquet file of schema md to w; set with parquet-go by package
+// github.com/csmadhu/gob/parquet
+var newParquetWriter func(md []string, w io.Writer) (hooks.ParquetWriter, error)
+
+// provider of parquet files is registered by package github.com/csmadhu/gob/parquet importing parquet-go
+func init() {
+	hooks.RegisterParquet = func(newWriter func(md []string, w io.Writer) (hooks.ParquetWriter, error)) {
+		newParquetWriter = newWriter
+		Register(DBProviderParquet, newFileSinkFactory(fileFormat{ext: "parquet", open: newParquetEncoder, compatible: parquetCompatible}))
+	}
+}
+
+func newParquetEncoder(w io.Writer, columns []fileColumn) (fileEncoder, error) {
+	var md []string
+	for _, column := range columns {
+		md = append(md, fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", column.name, parquetType(column.typ)))
+	}
+
+	pw, err := newParquetWriter(md, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parquetEncoder{w: pw, columns: columns}, nil
+}
+
+// parquetType returns physical and logical type of column type; values not of a primitive type are written as JSON text
+func parquetType(typ columnType) string {
+	switch typ.kind {
+	case kindInt:
+		return "type=INT64"
+	case kindFloat:
+		return "type=DOUBLE"
+	case kindBool:
+		return "type=BOOLEAN"
+	case kindTime:
+		return "type=TIMESTAMP_MILLIS"
+	case kindBytes:
+		return "type=BYTE_ARRAY"
+	}
+
+	return "type=UTF8"
+}
+
+// parquetCompatible reports whether values of type fit column of file
+func parquetCompatible(column, typ columnType) bool {
+	return typ.kind == kindAny || parquetType(column) == parquetType(typ)
+}
+
+// parquetValue converts value to Go type of parquet type of column
+func parquetValue(value interface{}, typ columnType) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch typ.kind {
+	case kindInt:
+		return coerceInt(value, 64)
+	case kindFloat:
+		f, err := coerceFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(f).Float(), nil
+	case kindBool:
+		return coerceBool(value)
+	case kindTime:
+		t, err := coerceTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return t.(time.Time).UnixNano() / int64(time.Millisecond), nil
+	case kindBytes:
+		b, err := coerceBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(b.([]byte)), nil
+	}
+
+	return fileString(value)
+}
+
+func (e *parquetEncoder) write(op ConflictAction, row Row) error {
+	record := []interface{}{string(op)}
+	for _, column := range e.columns[1:] {
+		value, err := parquetValue(row.Value(column.name), column.typ)
+		if err != nil {
+			return fmt.Errorf("convert column %s: %w", column.name, err)
+		}
+		record = append(record, value)
+	}
+
+	return e.w.Write(record)
+}
+
+// flush rows of batch as row group
+func (e *parquetEncoder) flush() error {
+	return e.w.Flush(true)
+}
+
+// close writes footer
+func (e *parquetEncoder) close() error {
+	return e.w.WriteStop()
+}
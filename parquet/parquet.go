@@ -0,0 +1,15 @@
+// Package parquet registers gob.DBProviderParquet writing files with github.com/xitongsys/parquet-go; import it for
+// its side effect
+//
+//	import _ "github.com/csmadhu/gob/parquet"
+package parquet
+
+import (
+	_ "github.com/csmadhu/gob" // sets hooks
+	"github.com/csmadhu/gob/internal/driver/parquet"
+	"github.com/csmadhu/gob/internal/hooks"
+)
+
+func init() {
+	hooks.RegisterParquet(parquet.NewWriter)
+}
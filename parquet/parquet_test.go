@@ -0,0 +1,38 @@
+package parquet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/csmadhu/gob"
+)
+
+func TestRegister(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gob")
+	if err != nil {
+		t.Fatalf("create temp dir err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := gob.New(gob.WithDBProvider(gob.DBProviderParquet), gob.WithDBConnStr(dir))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+
+	if err := g.Upsert(context.Background(), gob.UpsertArgs{
+		Model:          "students",
+		Keys:           []string{"name"},
+		ConflictAction: gob.ConflictActionUpdate,
+		Rows:           []gob.Row{{"name": "name-0", "age": 0}},
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+	g.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "students-000000.parquet")); err != nil {
+		t.Fatalf("parquet file err: %v", err)
+	}
+}
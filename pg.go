@@ -2,79 +2,493 @@ package gob
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// pgColumnsSQL lists columns of table in table order
+const pgColumnsSQL = `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull, a.atthasdef OR a.attidentity <> ''
+FROM pg_catalog.pg_attribute a
+WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`
+
+// pgUndefinedTable is SQLSTATE of undefined_table
+const pgUndefinedTable = "42P01"
+
+// pgSerializationFailure is SQLSTATE of serialization_failure; transaction may succeed when retried
+const pgSerializationFailure = "40001"
+
+// pgKeysSQL lists columns of unique indexes of table excluding partial and expression indexes
+const pgKeysSQL = `SELECT i.indisprimary, c.relname, a.attname
+FROM pg_catalog.pg_index i
+JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+WHERE i.indrelid = $1::regclass AND i.indisunique AND i.indpred IS NULL AND i.indexprs IS NULL
+ORDER BY i.indisprimary DESC, c.relname, array_position(i.indkey::int2[], a.attnum)`
+
+// pgIdentifier matches plain or double quoted identifier
+var pgIdentifier = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_$]*|"([^"]|"")+")$`)
+
+// pgDialect adjusts statements and transactions to databases speaking PostgreSQL wire protocol
+type pgDialect struct {
+	upsertInto bool // render UPSERT INTO when resolving conflicts on primary key by update
+	retries    int  // max number of retries of transaction failed with serialization failure
+}
+
+var (
+	pgDialectCockroach = pgDialect{upsertInto: true, retries: 5}
+	pgDialectYugabyte  = pgDialect{retries: 5}
+)
+
 type pg struct {
 	*pgxpool.Pool
+	dialect  pgDialect
+	observer observer
 }
 
-func newPg(args connArgs) (db, error) {
-	config, err := pgxpool.ParseConfig(args.connStr)
+func init() {
+	Register(DBProviderPg, newPg)
+	Register(DBProviderCockroach, newCockroach)
+	Register(DBProviderYugabyte, newYugabyte)
+}
+
+func newPg(args ConnArgs) (Provider, error) {
+	return newPgDialect(args, pgDialect{})
+}
+
+func newCockroach(args ConnArgs) (Provider, error) {
+	return newPgDialect(args, pgDialectCockroach)
+}
+
+func newYugabyte(args ConnArgs) (Provider, error) {
+	return newPgDialect(args, pgDialectYugabyte)
+}
+
+func newPgDialect(args ConnArgs, dialect pgDialect) (Provider, error) {
+	config, err := pgxpool.ParseConfig(args.ConnStr)
 	if err != nil {
 		return nil, fmt.Errorf("gob: parseConfig: %w", err)
 	}
 
-	config.MaxConnIdleTime = args.connIdleTime
-	config.MaxConnLifetime = args.connLifeTime
-	config.MinConns = int32(args.idleConns)
-	config.MaxConns = int32(args.openConns)
+	config.MaxConnIdleTime = args.ConnIdleTime
+	config.MaxConnLifetime = args.ConnLifeTime
+	config.MinConns = int32(args.IdleConns)
+	config.MaxConns = int32(args.OpenConns)
 
 	pool, err := pgxpool.ConnectConfig(context.Background(), config)
 	if err != nil {
 		return nil, fmt.Errorf("gob: connect to PostgreSQL server: %w", err)
 	}
 
-	return &pg{Pool: pool}, nil
+	return &pg{Pool: pool, dialect: dialect, observer: newObserver(args, "PostgreSQL")}, nil
+}
+
+func (db *pg) Close() {
+	db.Pool.Close()
+}
+
+func (db *pg) Metadata(ctx context.Context, model string) (*Metadata, error) {
+	metadata := &Metadata{Model: model}
+	if err := db.columns(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	if err := db.keys(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (db *pg) columns(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	rows, err := db.Query(ctx, pgColumnsSQL, model)
+	if err != nil {
+		return pgMetadataErr(model, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var column Column
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &column.Default); err != nil {
+			return fmt.Errorf("gob: scan columns of model '%s' on PostgreSQL server: %w", model, err)
+		}
+
+		column.typ = pgColumnType(column.Type)
+		metadata.Columns = append(metadata.Columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return pgMetadataErr(model, err)
+	}
+
+	return nil
+}
+
+func pgMetadataErr(model string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable {
+		return fmt.Errorf("%w '%s' on PostgreSQL server", ErrModelNotFound, model)
+	}
+
+	return fmt.Errorf("gob: query columns of model '%s' on PostgreSQL server: %w", model, err)
+}
+
+// pgColumnType maps type formatted by format_type to column type
+func pgColumnType(typ string) columnType {
+	typ = strings.ToLower(typ)
+	if strings.HasSuffix(typ, "[]") {
+		return columnType{kind: kindList}
+	}
+
+	// remove type modifiers e.g. character varying(255)
+	if idx := strings.Index(typ, "("); idx >= 0 {
+		typ = strings.TrimSpace(typ[:idx])
+	}
+
+	switch {
+	case typ == "smallint":
+		return columnType{kind: kindInt, bits: 16}
+	case typ == "integer":
+		return columnType{kind: kindInt, bits: 32}
+	case typ == "bigint":
+		return columnType{kind: kindInt, bits: 64}
+	case typ == "real", typ == "double precision", typ == "numeric":
+		return columnType{kind: kindFloat}
+	case typ == "boolean":
+		return columnType{kind: kindBool}
+	case typ == "text", typ == "character varying", typ == "character", typ == "citext", typ == "name":
+		return columnType{kind: kindString}
+	case strings.HasPrefix(typ, "timestamp"):
+		return columnType{kind: kindTime}
+	case typ == "date":
+		return columnType{kind: kindDate}
+	case typ == "uuid":
+		return columnType{kind: kindUUID}
+	case typ == "bytea":
+		return columnType{kind: kindBytes}
+	case typ == "json", typ == "jsonb":
+		return columnType{kind: kindJSON}
+	}
+
+	return columnType{kind: kindAny}
+}
+
+// pgTypeName returns PostgreSQL type of column type
+func pgTypeName(typ columnType) string {
+	switch typ.kind {
+	case kindInt:
+		switch typ.bits {
+		case 8, 16:
+			return "smallint"
+		case 32:
+			return "integer"
+		}
+		return "bigint"
+	case kindFloat:
+		return "double precision"
+	case kindBool:
+		return "boolean"
+	case kindTime:
+		return "timestamp with time zone"
+	case kindDate:
+		return "date"
+	case kindUUID:
+		return "uuid"
+	case kindBytes:
+		return "bytea"
+	case kindJSON, kindMap:
+		return "jsonb"
+	case kindList:
+		if typ.elem != nil && typ.elem.kind != kindList {
+			return pgTypeName(*typ.elem) + "[]"
+		}
+		return "jsonb"
+	}
+
+	return "text"
+}
+
+func (db *pg) AddColumn(ctx context.Context, model, column string, value interface{}) error {
+	return db.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", model, column, pgTypeName(inferType(value))))
+}
+
+func (db *pg) exec(ctx context.Context, sql string) error {
+	if _, err := db.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on PostgreSQL server: %w", sql, err)
+	}
+
+	return nil
+}
+
+// pgCreateModelSQL returns CREATE TABLE statement of schema
+func pgCreateModelSQL(schema modelSchema) string {
+	var defs []string
+	for _, column := range schema.columns {
+		def := fmt.Sprintf("%s %s", column.name, pgTypeName(column.typ))
+		if column.key {
+			def = def + " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s))",
+		schema.model,
+		strings.Join(defs, ", "),
+		strings.Join(schema.keys, ", "),
+	)
+}
+
+func (db *pg) CreateModel(ctx context.Context, args UpsertArgs, sampleSize int) error {
+	schema, err := inferModelSchema(args, sampleSize)
+	if err != nil {
+		return err
+	}
+
+	return db.exec(ctx, pgCreateModelSQL(schema))
 }
 
-func (db *pg) close() {
-	db.Close()
+func (db *pg) keys(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	rows, err := db.Query(ctx, pgKeysSQL, model)
+	if err != nil {
+		return fmt.Errorf("gob: query keys of model '%s' on PostgreSQL server: %w", model, err)
+	}
+	defer rows.Close()
+
+	var (
+		indexes []string
+		columns = make(map[string][]string)
+	)
+
+	for rows.Next() {
+		var (
+			primary bool
+			index   string
+			column  string
+		)
+
+		if err := rows.Scan(&primary, &index, &column); err != nil {
+			return fmt.Errorf("gob: scan keys of model '%s' on PostgreSQL server: %w", model, err)
+		}
+
+		if primary {
+			metadata.PrimaryKey = append(metadata.PrimaryKey, column)
+			continue
+		}
+
+		if _, ok := columns[index]; !ok {
+			indexes = append(indexes, index)
+		}
+		columns[index] = append(columns[index], column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("gob: read keys of model '%s' on PostgreSQL server: %w", model, err)
+	}
+
+	for _, index := range indexes {
+		metadata.UniqueKeys = append(metadata.UniqueKeys, columns[index])
+	}
+
+	return nil
 }
 
-func (db *pg) upsert(ctx context.Context, upsertArgs UpsertArgs) error {
+func (db *pg) Upsert(ctx context.Context, upsertArgs UpsertArgs) error {
 	if len(upsertArgs.Rows) == 0 {
 		return nil
 	}
 
-	if len(upsertArgs.Keys) == 0 {
-		return ErrEmptykeys
+	statements, err := db.Build(upsertArgs)
+	if err != nil {
+		return err
 	}
+	statements = append(statements, upsertArgs.extra...)
 
-	// start transaction
-	tx, err := db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadWrite, DeferrableMode: pgx.NotDeferrable})
-	if err != nil {
-		return fmt.Errorf("gob: begin PostgreSQL tx: %w", err)
+	for attempt := 0; ; attempt++ {
+		err := db.upsertTx(ctx, upsertArgs, statements)
+		if err == nil || attempt >= db.dialect.retries || !pgRetryable(err) {
+			return err
+		}
+
+		// back off before retrying transaction
+		backoff := pgRetryBackoff(attempt)
+		db.observer.retry(ctx, upsertArgs.Model, attempt+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
 	}
+}
+
+// pgRetryable reports whether transaction failed with serialization failure
+func pgRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgSerializationFailure
+}
 
+// pgRowError reports whether err is caused by values of a row: data exceptions (SQLSTATE class 22) and
+// integrity constraint violations (class 23)
+func pgRowError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23"))
+}
+
+// pgRetryBackoff returns exponential delay before retry attempt starting at 10ms
+func pgRetryBackoff(attempt int) time.Duration {
+	return (10 * time.Millisecond) << attempt
+}
+
+// Build statements of rows of batch
+func (db *pg) Build(upsertArgs UpsertArgs) ([]Statement, error) {
+	if err := validatePgConflictTarget(upsertArgs); err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
 	for _, row := range upsertArgs.Rows {
 		if row.Len() == 0 {
 			continue // ignore empty row
 		}
 
 		sql, args := db.rowToSQL(row, upsertArgs)
-		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		statements = append(statements, Statement{SQL: sql, Args: args})
+	}
+
+	return statements, nil
+}
+
+func (db *pg) upsertTx(ctx context.Context, upsertArgs UpsertArgs, statements []Statement) error {
+	// wait before transaction to not hold locks
+	if err := db.observer.wait(ctx, len(statements)); err != nil {
+		return err
+	}
+
+	// start transaction
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadWrite, DeferrableMode: pgx.NotDeferrable})
+	if err != nil {
+		return fmt.Errorf("gob: begin PostgreSQL tx: %w", err)
+	}
+
+	for _, statement := range statements {
+		stmtCtx, done := db.observer.statementContext(ctx)
+		t0 := time.Now()
+		_, err := tx.Exec(stmtCtx, statement.SQL, statement.Args...)
+		db.observer.statement(ctx, statement.SQL, t0)
+		if err != nil {
 			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
-				return fmt.Errorf("gob: execute upsert sql '%s' on PostgreSQL server: %v rollback tx: %w", sql, err, rollbackErr)
+				return done(fmt.Errorf("gob: execute upsert sql '%s' on PostgreSQL server: %w rollback tx: %v", statement.SQL, err, rollbackErr))
 			}
-			return fmt.Errorf("gob: execute upsert sql '%s' on PostgreSQL server: %w", sql, err)
+			return done(fmt.Errorf("gob: execute upsert sql '%s' on PostgreSQL server: %w", statement.SQL, err))
 		}
+		done(nil)
 	}
 
 	// commit transaction
-	if err := tx.Commit(ctx); err != nil {
+	spanCtx, span := db.observer.commit(ctx, upsertArgs.Model, len(statements))
+	err = tx.Commit(spanCtx)
+	endSpan(span, err)
+	if err != nil {
 		return fmt.Errorf("gob: commit PostgreSQL tx: %w", err)
 	}
 
 	return nil
 }
 
+// validatePgConflictTarget verifies ON CONFLICT target before starting the transaction
+func validatePgConflictTarget(upsertArgs UpsertArgs) error {
+	if upsertArgs.Constraint == "" && len(upsertArgs.Keys) == 0 {
+		return ErrEmptykeys
+	}
+
+	if upsertArgs.Constraint != "" {
+		if upsertArgs.KeyPredicate != "" {
+			return fmt.Errorf("%w key predicate not allowed with constraint %s", ErrInvalidConflictTarget, upsertArgs.Constraint)
+		}
+
+		if !pgIdentifier.MatchString(upsertArgs.Constraint) {
+			return fmt.Errorf("%w invalid constraint name %s", ErrInvalidConflictTarget, upsertArgs.Constraint)
+		}
+	}
+
+	for _, key := range upsertArgs.Keys {
+		if !pgValidKey(key) {
+			return fmt.Errorf("%w invalid key %s", ErrInvalidConflictTarget, key)
+		}
+	}
+
+	if upsertArgs.KeyPredicate != "" && !pgValidExpr(upsertArgs.KeyPredicate) {
+		return fmt.Errorf("%w invalid key predicate %s", ErrInvalidConflictTarget, upsertArgs.KeyPredicate)
+	}
+
+	return nil
+}
+
+// pgValidKey reports whether key is a column name or a function of column names e.g. lower(email)
+func pgValidKey(key string) bool {
+	return pgIdentifier.MatchString(key) || keyFunc.MatchString(key)
+}
+
+// pgValidExpr reports whether expr is non empty, has balanced parentheses, a single statement and no comments;
+// expr is trusted SQL, the checks catch mistakes rather than untrusted input
+func pgValidExpr(expr string) bool {
+	if strings.TrimSpace(expr) == "" || strings.Contains(expr, ";") ||
+		strings.Contains(expr, "--") || strings.Contains(expr, "/*") {
+		return false
+	}
+
+	depth := 0
+	for _, r := range expr {
+		switch r {
+		case '(':
+			depth = depth + 1
+		case ')':
+			depth = depth - 1
+		}
+
+		if depth < 0 {
+			return false
+		}
+	}
+
+	return depth == 0
+}
+
+// pgConflictTarget returns constraint or index inference clause ON CONFLICT
+func pgConflictTarget(upsertArgs UpsertArgs) string {
+	if upsertArgs.Constraint != "" {
+		return fmt.Sprintf("ON CONSTRAINT %s", upsertArgs.Constraint)
+	}
+
+	target := fmt.Sprintf("(%s)", strings.Join(upsertArgs.Keys, ","))
+	if upsertArgs.KeyPredicate != "" {
+		target = fmt.Sprintf("%s WHERE %s", target, upsertArgs.KeyPredicate)
+	}
+
+	return target
+}
+
+// useUpsertInto reports whether dialect resolves conflicts of rows with UPSERT INTO; UPSERT INTO
+// always resolves conflicts on primary key
+func (db *pg) useUpsertInto(upsertArgs UpsertArgs) bool {
+	return db.dialect.upsertInto &&
+		upsertArgs.primaryKey &&
+		upsertArgs.ConflictAction == ConflictActionUpdate &&
+		upsertArgs.Constraint == "" &&
+		upsertArgs.KeyPredicate == ""
+}
+
 func (db *pg) rowToSQL(row Row, upsertArgs UpsertArgs) (sql string, args []interface{}) {
-	upsertSQL := "INSERT INTO %s(%s) VALUES(%s) ON CONFLICT (%s) %s"
+	upsertSQL := "INSERT INTO %s(%s) VALUES(%s) ON CONFLICT %s %s"
 
 	var (
 		cols         []string
@@ -87,13 +501,18 @@ func (db *pg) rowToSQL(row Row, upsertArgs UpsertArgs) (sql string, args []inter
 	for _, column := range row.Columns() {
 		cols = append(cols, column)
 		values = append(values, fmt.Sprintf("$%d", count))
-		if !upsertArgs.keySet.Contains(column) {
+		if !upsertArgs.IsKey(column) {
 			updateClause = append(updateClause, fmt.Sprintf("%s=$%d", column, count))
 		}
 		args = append(args, row.Value(column))
 		count = count + 1
 	}
 
+	if db.useUpsertInto(upsertArgs) {
+		sql = fmt.Sprintf("UPSERT INTO %s(%s) VALUES(%s)", upsertArgs.Model, strings.Join(cols, ","), strings.Join(values, ","))
+		return sql, args
+	}
+
 	switch upsertArgs.ConflictAction {
 	case ConflictActionUpdate:
 		action = fmt.Sprintf("DO UPDATE SET %s", strings.Join(updateClause, ","))
@@ -105,9 +524,59 @@ func (db *pg) rowToSQL(row Row, upsertArgs UpsertArgs) (sql string, args []inter
 		upsertArgs.Model,
 		strings.Join(cols, ","),
 		strings.Join(values, ","),
-		strings.Join(upsertArgs.Keys, ","),
+		pgConflictTarget(upsertArgs),
 		action,
 	)
 
 	return sql, args
 }
+
+// pgRow returns sql.ErrNoRows instead of pgx.ErrNoRows
+type pgRow struct {
+	pgx.Row
+}
+
+func (row pgRow) Scan(dest ...interface{}) error {
+	if err := row.Row.Scan(dest...); err != pgx.ErrNoRows {
+		return err
+	}
+
+	return sql.ErrNoRows
+}
+
+func (db *pg) queryRow(ctx context.Context, sql string, args ...interface{}) rowScanner {
+	return pgRow{db.QueryRow(ctx, sql, args...)}
+}
+
+// Ping a connection of pool
+func (db *pg) Ping(ctx context.Context) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("gob: acquire PostgreSQL conn: %w", err)
+	}
+	defer conn.Release()
+
+	if err := conn.Conn().Ping(ctx); err != nil {
+		return fmt.Errorf("gob: ping PostgreSQL server: %w", err)
+	}
+
+	return nil
+}
+
+func (db *pg) placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (db *pg) typeName(typ columnType) string {
+	return pgTypeName(typ)
+}
+
+// requiresConflictTarget of ON CONFLICT
+func (db *pg) requiresConflictTarget() bool {
+	return true
+}
+
+// upsertsPrimaryKey of dialects rendering UPSERT INTO
+func (db *pg) upsertsPrimaryKey() bool {
+	return db.dialect.upsertInto
+}
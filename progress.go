@@ -0,0 +1,36 @@
+package gob
+
+import "time"
+
+// Progress of Gob.Upsert reported after each committed batch
+type Progress struct {
+	Model        string
+	Rows         int           // rows of committed batches
+	TotalRows    int           // rows of upsert
+	Batches      int           // committed batches
+	TotalBatches int           // batches of upsert; estimated from size of next batch with WithAdaptiveBatchSize
+	Elapsed      time.Duration // since start of upsert
+	Remaining    time.Duration // estimated from rate of committed rows
+}
+
+// ProgressFunc receives progress of upserts; called synchronously between batches
+type ProgressFunc func(progress Progress)
+
+// newProgress returns progress of rows of batches committed since t0; remaining rows are upserted in batches
+// of batchSize
+func newProgress(model string, rows, totalRows, batches, batchSize int, t0 time.Time) Progress {
+	progress := Progress{
+		Model:        model,
+		Rows:         rows,
+		TotalRows:    totalRows,
+		Batches:      batches,
+		TotalBatches: batches + (totalRows-rows+batchSize-1)/batchSize,
+		Elapsed:      time.Since(t0),
+	}
+
+	if rows > 0 {
+		progress.Remaining = time.Duration(float64(progress.Elapsed) / float64(rows) * float64(totalRows-rows))
+	}
+
+	return progress
+}
@@ -0,0 +1,93 @@
+package gob
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var progresses []Progress
+	gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(10), WithProgress(func(progress Progress) {
+		progresses = append(progresses, progress)
+	}))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           testGenStudentRowsMySQL(25),
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	var got [][]int
+	for _, progress := range progresses {
+		if progress.Model != "students" || progress.TotalRows != 25 || progress.TotalBatches != 3 {
+			t.Fatalf("progress got: %+v want: 3 batches of 25 rows of students", progress)
+		}
+		got = append(got, []int{progress.Rows, progress.Batches})
+	}
+
+	if want := [][]int{{10, 1}, {20, 2}, {25, 3}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows and batches got: %v want: %v", got, want)
+	}
+
+	if last := progresses[len(progresses)-1]; last.Remaining != 0 {
+		t.Fatalf("remaining of last batch got: %v want: 0", last.Remaining)
+	}
+
+	if _, err := New(WithDBProvider(DBProviderMemory), WithProgress(nil)); err == nil {
+		t.Fatalf("init gob with nil progress; want err")
+	}
+}
+
+func TestNewProgress(t *testing.T) {
+	t0 := time.Now().Add(-time.Minute)
+	progress := newProgress("students", 250, 1000, 1, 250, t0)
+
+	if progress.TotalBatches != 4 {
+		t.Fatalf("total batches got: %d want: 4", progress.TotalBatches)
+	}
+
+	// three times the elapsed time for three times the rows
+	if remaining := progress.Remaining; remaining < 3*time.Minute || remaining > 3*progress.Elapsed {
+		t.Fatalf("remaining got: %v want: 3 times %v", remaining, progress.Elapsed)
+	}
+}
+
+func TestProgressAdaptiveBatchSize(t *testing.T) {
+	for _, target := range []time.Duration{time.Nanosecond, time.Hour} {
+		var progresses []Progress
+		gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(100), WithAdaptiveBatchSize(target, 5, 400),
+			WithProgress(func(progress Progress) { progresses = append(progresses, progress) }))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(2000),
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+		gob.Close()
+
+		for _, progress := range progresses {
+			if progress.Batches > progress.TotalBatches {
+				t.Fatalf("target %v batches %d > total %d, rows %d/%d", target, progress.Batches, progress.TotalBatches, progress.Rows, progress.TotalRows)
+			}
+		}
+
+		if last := progresses[len(progresses)-1]; last.Batches != last.TotalBatches || last.Rows != 2000 {
+			t.Fatalf("target %v last progress got: %+v want: all batches of 2000 rows", target, last)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package gob
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPrometheusBuckets of batch latency in seconds
+var defaultPrometheusBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// prometheusMetric exposed by PrometheusCollector
+type prometheusMetric struct {
+	name string
+	typ  string
+	help string
+}
+
+// prometheusMetrics in exposition order
+var prometheusMetrics = []prometheusMetric{
+	{"gob_rows_total", "counter", "Rows of committed batches."},
+	{"gob_batches_total", "counter", "Batches by status."},
+	{"gob_batch_duration_seconds", "histogram", "Latency of batches."},
+	{"gob_errors_total", "counter", "Failed batches by error class."},
+	{"gob_retries_total", "counter", "Retries of transactions."},
+	{"gob_pool_connections", "gauge", "Connections of pool by state."},
+	{"gob_pool_wait_total", "counter", "Connections waited for."},
+	{"gob_pool_wait_seconds_total", "counter", "Time waited for connections."},
+	{"gob_pool_hosts", "gauge", "Hosts of Cassandra by state."},
+}
+
+// PrometheusCollector is Metrics exposing measurements in Prometheus text format over HTTP
+type PrometheusCollector struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	values     map[string]map[string]float64 // value per labels per metric of counters and gauges
+	histograms map[string]*prometheusHistogram
+}
+
+// prometheusHistogram of batch latency per labels
+type prometheusHistogram struct {
+	counts []uint64 // cumulative count per bucket
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusCollector returns collector with buckets of batch latency in seconds; defaults from 5ms to 60s if empty
+func NewPrometheusCollector(buckets ...float64) *PrometheusCollector {
+	if len(buckets) == 0 {
+		buckets = defaultPrometheusBuckets
+	}
+
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusCollector{
+		buckets:    buckets,
+		values:     make(map[string]map[string]float64),
+		histograms: make(map[string]*prometheusHistogram),
+	}
+}
+
+// Batch counts rows, batches and errors and observes latency of batch
+func (c *PrometheusCollector) Batch(provider DBProvider, model string, rows int, d time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	labels := prometheusLabels("provider", string(provider), "model", model)
+	status := "committed"
+	if err != nil {
+		status = "failed"
+		c.add("gob_errors_total", prometheusLabels("provider", string(provider), "model", model, "class", ErrorClass(err)), 1)
+	} else {
+		c.add("gob_rows_total", labels, float64(rows))
+	}
+	c.add("gob_batches_total", prometheusLabels("provider", string(provider), "model", model, "status", status), 1)
+
+	h, ok := c.histograms[labels]
+	if !ok {
+		h = &prometheusHistogram{counts: make([]uint64, len(c.buckets))}
+		c.histograms[labels] = h
+	}
+
+	seconds := d.Seconds()
+	for idx, bound := range c.buckets {
+		if seconds <= bound {
+			h.counts[idx]++
+		}
+	}
+	h.count++
+	h.sum = h.sum + seconds
+}
+
+// Retry counts retry of transaction
+func (c *PrometheusCollector) Retry(provider DBProvider, model string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.add("gob_retries_total", prometheusLabels("provider", string(provider), "model", model), 1)
+}
+
+// Pool sets gauges of connections of pool and totals of waits; hosts of Cassandra if reported
+func (c *PrometheusCollector) Pool(provider DBProvider, stats PoolStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, state := range []struct {
+		name  string
+		value int
+	}{{"open", stats.Open}, {"idle", stats.Idle}, {"in_use", stats.InUse}} {
+		c.set("gob_pool_connections", prometheusLabels("provider", string(provider), "state", state.name), float64(state.value))
+	}
+
+	// totals of pool
+	labels := prometheusLabels("provider", string(provider))
+	c.set("gob_pool_wait_total", labels, float64(stats.WaitCount))
+	c.set("gob_pool_wait_seconds_total", labels, stats.WaitDuration.Seconds())
+
+	if stats.HostsUp+stats.HostsDown > 0 {
+		c.set("gob_pool_hosts", prometheusLabels("provider", string(provider), "state", "up"), float64(stats.HostsUp))
+		c.set("gob_pool_hosts", prometheusLabels("provider", string(provider), "state", "down"), float64(stats.HostsDown))
+	}
+}
+
+// ServeHTTP writes measurements in Prometheus text format
+func (c *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes measurements in Prometheus text format to w
+func (c *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b strings.Builder
+	for _, metric := range prometheusMetrics {
+		if metric.typ == "histogram" {
+			c.writeHistograms(&b, metric)
+			continue
+		}
+
+		values := c.values[metric.name]
+		if len(values) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.typ)
+		for _, labels := range prometheusSortedKeys(values) {
+			fmt.Fprintf(&b, "%s{%s} %s\n", metric.name, labels, prometheusValue(values[labels]))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (c *PrometheusCollector) writeHistograms(b *strings.Builder, metric prometheusMetric) {
+	if len(c.histograms) == 0 {
+		return
+	}
+
+	labelSets := make([]string, 0, len(c.histograms))
+	for labels := range c.histograms {
+		labelSets = append(labelSets, labels)
+	}
+	sort.Strings(labelSets)
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.typ)
+	for _, labels := range labelSets {
+		h := c.histograms[labels]
+		for idx, bound := range c.buckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", metric.name, labels, prometheusValue(bound), h.counts[idx])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", metric.name, labels, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", metric.name, labels, prometheusValue(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", metric.name, labels, h.count)
+	}
+}
+
+func (c *PrometheusCollector) add(name, labels string, value float64) {
+	if c.values[name] == nil {
+		c.values[name] = make(map[string]float64)
+	}
+	c.values[name][labels] = c.values[name][labels] + value
+}
+
+func (c *PrometheusCollector) set(name, labels string, value float64) {
+	if c.values[name] == nil {
+		c.values[name] = make(map[string]float64)
+	}
+	c.values[name][labels] = value
+}
+
+// prometheusLabels renders pairs of label names and values e.g. provider="postgres",model="students"
+func prometheusLabels(pairs ...string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	labels := make([]string, 0, len(pairs)/2)
+	for idx := 0; idx+1 < len(pairs); idx += 2 {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, pairs[idx], escaper.Replace(pairs[idx+1])))
+	}
+
+	return strings.Join(labels, ",")
+}
+
+func prometheusValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func prometheusSortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
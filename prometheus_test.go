@@ -0,0 +1,88 @@
+package gob
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	c := NewPrometheusCollector(0.1, 1)
+	c.Batch(DBProviderPg, "students", 10, 50*time.Millisecond, nil)
+	c.Batch(DBProviderPg, "students", 5, 500*time.Millisecond, nil)
+	c.Batch(DBProviderPg, "students", 5, 2*time.Second, ErrEmptykeys)
+	c.Batch(DBProviderPg, `"quoted"`, 1, time.Millisecond, errors.New("syntax error"))
+	c.Retry(DBProviderPg, "students")
+	c.Pool(DBProviderPg, PoolStats{Open: 3, Idle: 2, InUse: 1, WaitCount: 4, WaitDuration: 1500 * time.Millisecond})
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Fatalf("content type got: %s", got)
+	}
+
+	want := `# HELP gob_rows_total Rows of committed batches.
+# TYPE gob_rows_total counter
+gob_rows_total{provider="pg",model="students"} 15
+# HELP gob_batches_total Batches by status.
+# TYPE gob_batches_total counter
+gob_batches_total{provider="pg",model="\"quoted\"",status="failed"} 1
+gob_batches_total{provider="pg",model="students",status="committed"} 2
+gob_batches_total{provider="pg",model="students",status="failed"} 1
+# HELP gob_batch_duration_seconds Latency of batches.
+# TYPE gob_batch_duration_seconds histogram
+gob_batch_duration_seconds_bucket{provider="pg",model="\"quoted\"",le="0.1"} 1
+gob_batch_duration_seconds_bucket{provider="pg",model="\"quoted\"",le="1"} 1
+gob_batch_duration_seconds_bucket{provider="pg",model="\"quoted\"",le="+Inf"} 1
+gob_batch_duration_seconds_sum{provider="pg",model="\"quoted\""} 0.001
+gob_batch_duration_seconds_count{provider="pg",model="\"quoted\""} 1
+gob_batch_duration_seconds_bucket{provider="pg",model="students",le="0.1"} 1
+gob_batch_duration_seconds_bucket{provider="pg",model="students",le="1"} 2
+gob_batch_duration_seconds_bucket{provider="pg",model="students",le="+Inf"} 3
+gob_batch_duration_seconds_sum{provider="pg",model="students"} 2.55
+gob_batch_duration_seconds_count{provider="pg",model="students"} 3
+# HELP gob_errors_total Failed batches by error class.
+# TYPE gob_errors_total counter
+gob_errors_total{provider="pg",model="\"quoted\"",class="database"} 1
+gob_errors_total{provider="pg",model="students",class="invalid_args"} 1
+# HELP gob_retries_total Retries of transactions.
+# TYPE gob_retries_total counter
+gob_retries_total{provider="pg",model="students"} 1
+# HELP gob_pool_connections Connections of pool by state.
+# TYPE gob_pool_connections gauge
+gob_pool_connections{provider="pg",state="idle"} 2
+gob_pool_connections{provider="pg",state="in_use"} 1
+gob_pool_connections{provider="pg",state="open"} 3
+# HELP gob_pool_wait_total Connections waited for.
+# TYPE gob_pool_wait_total counter
+gob_pool_wait_total{provider="pg"} 4
+# HELP gob_pool_wait_seconds_total Time waited for connections.
+# TYPE gob_pool_wait_seconds_total counter
+gob_pool_wait_seconds_total{provider="pg"} 1.5
+`
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("exposition got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrometheusCollectorHosts(t *testing.T) {
+	c := NewPrometheusCollector()
+	c.Pool(DBProviderCassandra, PoolStats{HostsUp: 2, HostsDown: 1})
+
+	var b strings.Builder
+	if _, err := c.WriteTo(&b); err != nil {
+		t.Fatalf("write err: %v", err)
+	}
+
+	want := `# HELP gob_pool_hosts Hosts of Cassandra by state.
+# TYPE gob_pool_hosts gauge
+gob_pool_hosts{provider="cassandra",state="down"} 1
+gob_pool_hosts{provider="cassandra",state="up"} 2
+`
+	if got := b.String(); !strings.HasSuffix(got, want) {
+		t.Fatalf("metrics got:\n%s\nwant suffix:\n%s", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+package gob
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Provider upserts rows to a database; providers are made available to WithDBProvider by Register
+type Provider interface {
+	// Upsert rows of batch to model resolving conflicts on keys with conflict action; empty rows must be
+	// ignored as rows rejected by WithDeadLetter are replaced by empty rows keeping index of rows of batch.
+	// Rows of a batch are upserted atomically where the database allows
+	Upsert(ctx context.Context, args UpsertArgs) error
+
+	// Close the resources
+	Close()
+}
+
+// MetadataProvider is implemented by providers reading metadata of models from database catalog;
+// required to default keys to primary key, WithValidation, WithUnknownColumnPolicy and WithCreateModel
+type MetadataProvider interface {
+	// Metadata of model; returns error wrapping ErrModelNotFound if model does not exist
+	Metadata(ctx context.Context, model string) (*Metadata, error)
+}
+
+// Pinger is implemented by providers with health probe of database; required by Gob.Ping and probes of WithCircuitBreaker
+type Pinger interface {
+	// Ping database; returns error if database is unreachable
+	Ping(ctx context.Context) error
+}
+
+// StatsProvider is implemented by providers with connection pool; required by Gob.Stats and Metrics.Pool
+type StatsProvider interface {
+	// Stats of connections of pool to database
+	Stats() PoolStats
+}
+
+// conflictTargeter is implemented by providers whose upserts require keys or constraint as conflict target;
+// keys of their upserts default to primary key of model
+type conflictTargeter interface {
+	requiresConflictTarget() bool
+}
+
+// primaryKeyUpserter is implemented by providers rendering other statements for upserts on primary key of model;
+// metadata of model is read to compare keys of their upserts with primary key
+type primaryKeyUpserter interface {
+	upsertsPrimaryKey() bool
+}
+
+// ColumnAdder is implemented by providers able to alter models; required by UnknownColumnAdd
+type ColumnAdder interface {
+	// AddColumn adds column to model with type inferred from value
+	AddColumn(ctx context.Context, model, column string, value interface{}) error
+}
+
+// ModelCreator is implemented by providers able to create models; required by WithCreateModel
+type ModelCreator interface {
+	// CreateModel creates model with column types inferred from first sampleSize rows and primary key of keys
+	CreateModel(ctx context.Context, args UpsertArgs, sampleSize int) error
+}
+
+// ConnArgs to connect Provider to database
+type ConnArgs struct {
+	ConnStr      string        // database conn string
+	IdleConns    int           // max number of conns idle in pool
+	OpenConns    int           // max number of conns open to database
+	ConnIdleTime time.Duration // max amount of time conn may be idle
+	ConnLifeTime time.Duration // max amount of time conn may be reused
+
+	DBProvider    DBProvider    // provider connected by factory
+	Logger        Logger        // receives events of statements and transactions, nil to discard
+	Metrics       Metrics       // receives measurements of transactions, nil to discard
+	Tracer        Tracer        // starts spans of commits, nil to discard
+	SlowStatement time.Duration // log statements taking longer, 0 to disable
+
+	StatementsPerSecond float64       // max statements executed per second, 0 for no limit
+	StatementTimeout    time.Duration // of each statement, 0 for no timeout
+}
+
+// Factory connects Provider to database
+type Factory func(args ConnArgs) (Provider, error)
+
+// builtinProvider is implemented by providers shipped with gob
+type builtinProvider interface {
+	Provider
+	MetadataProvider
+	ColumnAdder
+	ModelCreator
+}
+
+var (
+	_ builtinProvider = (*pg)(nil)
+	_ builtinProvider = (*mysql)(nil)
+	_ builtinProvider = (*cassy)(nil)
+	_ builtinProvider = (*sqlite)(nil)
+	_ builtinProvider = (*mssql)(nil)
+	_ builtinProvider = (*Memory)(nil)
+)
+
+// builtin providers with database
+var (
+	_ Pinger        = (*pg)(nil)
+	_ Pinger        = (*sqlDB)(nil)
+	_ Pinger        = (*cassy)(nil)
+	_ Pinger        = (*mssql)(nil)
+	_ StatsProvider = (*pg)(nil)
+	_ StatsProvider = (*sqlDB)(nil)
+	_ StatsProvider = (*cassy)(nil)
+	_ StatsProvider = (*mssql)(nil)
+)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[DBProvider]Factory)
+)
+
+// providerPackages register providers requiring cgo or large dependencies when imported
+var providerPackages = map[DBProvider]string{
+	DBProviderSQLite:  "github.com/csmadhu/gob/sqlite",
+	DBProviderMSSQL:   "github.com/csmadhu/gob/mssql",
+	DBProviderParquet: "github.com/csmadhu/gob/parquet",
+}
+
+// Register makes provider available by name to WithDBProvider;
+// panics if factory is nil or Register is called twice with the same name
+func Register(name DBProvider, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("gob: Register factory is nil")
+	}
+
+	if _, dup := factories[name]; dup {
+		panic(fmt.Sprintf("gob: Register called twice for provider %s", name))
+	}
+
+	factories[name] = factory
+}
+
+// Providers returns sorted names of registered providers
+func Providers() []DBProvider {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	var names []DBProvider
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func lookupFactory(name DBProvider) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, ok := factories[name]
+	return factory, ok
+}
+
+// txDB is implemented by providers executing extra statements of UpsertArgs in transaction of batch
+type txDB interface {
+	Builder
+	exec(ctx context.Context, sql string) error
+	upsertTx(ctx context.Context, upsertArgs UpsertArgs, statements []Statement) error
+	queryRow(ctx context.Context, sql string, args ...interface{}) rowScanner
+	placeholder(n int) string
+	typeName(typ columnType) string // empty if type of database is unknown
+}
+
+// rowScanner of a queried row; Scan returns sql.ErrNoRows if no row is found
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// binder is implemented by stores in target database bound to provider by New
+type binder interface {
+	bind(ctx context.Context, provider Provider) error
+}
@@ -0,0 +1,105 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// testProvider records rows upserted to models
+type testProvider struct {
+	args   ConnArgs
+	rows   map[string][]Row
+	closed bool
+}
+
+func (p *testProvider) Upsert(ctx context.Context, args UpsertArgs) error {
+	p.rows[args.Model] = append(p.rows[args.Model], args.Rows...)
+	return nil
+}
+
+func (p *testProvider) Close() {
+	p.closed = true
+}
+
+func TestRegister(t *testing.T) {
+	var provider *testProvider
+	Register("test-registry", func(args ConnArgs) (Provider, error) {
+		provider = &testProvider{args: args, rows: make(map[string][]Row)}
+		return provider, nil
+	})
+
+	t.Run("builtin", func(t *testing.T) {
+		registered := make(map[DBProvider]bool)
+		for _, name := range Providers() {
+			registered[name] = true
+		}
+
+		for _, name := range []DBProvider{DBProviderPg, DBProviderMySQL, DBProviderCassandra, DBProviderSQLite, DBProviderMSSQL, DBProviderCockroach, DBProviderYugabyte, DBProviderMariaDB, DBProviderTiDB, DBProviderMemory, "test-registry"} {
+			if !registered[name] {
+				t.Fatalf("provider %s not registered", name)
+			}
+		}
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		gob, err := New(WithDBProvider("test-registry"), WithDBConnStr("test://"), WithBatchSize(2))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+
+		if provider.args.ConnStr != "test://" {
+			t.Fatalf("connStr got: %s want: test://", provider.args.ConnStr)
+		}
+
+		if gob.Provider() != provider {
+			t.Fatalf("provider got: %v want: %v", gob.Provider(), provider)
+		}
+
+		rows := []Row{{"name": "name-0"}, {"name": "name-1"}, {"name": "name-2"}}
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+			Rows:           rows,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		if got := len(provider.rows["students"]); got != len(rows) {
+			t.Fatalf("rows got: %d want: %d", got, len(rows))
+		}
+
+		gob.Close()
+		if !provider.closed || gob.Provider() != nil {
+			t.Fatalf("provider not closed")
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		gob, err := New(WithDBProvider("test-registry"), WithValidation(true))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+			Rows:           []Row{{"name": "name-0"}},
+		}); !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("upsert got: %v want: %v", err, ErrUnsupported)
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("register provider twice; want panic")
+			}
+		}()
+
+		Register(DBProviderPg, newPg)
+	})
+}
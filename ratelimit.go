@@ -0,0 +1,123 @@
+package gob
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces events to rate per second; safe for concurrent use
+type rateLimiter struct {
+	interval time.Duration // between events
+
+	mu   sync.Mutex
+	next time.Time // earliest time of next event
+}
+
+// newRateLimiter returns limiter of rate events per second; nil for no limit
+func newRateLimiter(rate float64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait blocks until n events are allowed or ctx is done; events of n wait for events reserved before.
+// Events of n are released if ctx is done before they are allowed
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(time.Duration(n) * l.interval)
+	reserved := l.next
+	l.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		if err := ctx.Err(); err != nil {
+			l.release(reserved, n)
+			return err
+		}
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.release(reserved, n)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// release n events reserved up to reserved unless events were reserved after them
+func (l *rateLimiter) release(reserved time.Time, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.next.Equal(reserved) {
+		l.next = l.next.Add(-time.Duration(n) * l.interval)
+	}
+}
+
+// adaptiveBatch adjusts size of batches of Gob to keep latency of batches near target
+type adaptiveBatch struct {
+	target   time.Duration
+	min, max int
+
+	mu   sync.Mutex
+	size int
+}
+
+func newAdaptiveBatch(target time.Duration, min, max, size int) *adaptiveBatch {
+	a := &adaptiveBatch{target: target, min: min, max: max, size: size}
+	a.clamp()
+	return a
+}
+
+// current size of batches
+func (a *adaptiveBatch) current() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.size
+}
+
+// observe batch upserted in d; size is halved on error, shrunk in proportion to latency above target
+// and grown by a quarter below target. Latency within a fifth of target keeps size
+func (a *adaptiveBatch) observe(d time.Duration, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case err != nil:
+		a.size = a.size / 2
+	case d > a.target+a.target/5:
+		a.size = int(float64(a.size) * float64(a.target) / float64(d))
+	case d < a.target-a.target/5:
+		a.size = a.size + a.size/4 + 1
+	}
+
+	a.clamp()
+}
+
+func (a *adaptiveBatch) clamp() {
+	if a.size < a.min {
+		a.size = a.min
+	}
+
+	if a.size > a.max {
+		a.size = a.max
+	}
+}
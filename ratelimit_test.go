@@ -0,0 +1,192 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	if l := newRateLimiter(0); l != nil {
+		t.Fatalf("limiter of rate 0 got: %v want: nil", l)
+	}
+
+	// nil limiter does not wait
+	var l *rateLimiter
+	if err := l.wait(context.Background(), 1000); err != nil {
+		t.Fatalf("wait of nil limiter err: %v", err)
+	}
+
+	l = newRateLimiter(100)
+	t0 := time.Now()
+	for idx := 0; idx < 3; idx++ {
+		if err := l.wait(context.Background(), 10); err != nil {
+			t.Fatalf("wait err: %v", err)
+		}
+	}
+
+	// third batch waits for 20 events of batches before
+	if elapsed := time.Since(t0); elapsed < 190*time.Millisecond {
+		t.Fatalf("elapsed got: %v want: >= 200ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wait of canceled ctx got: %v want: %v", err, context.Canceled)
+	}
+
+	t.Run("release", func(t *testing.T) {
+		l := newRateLimiter(100)
+		if err := l.wait(context.Background(), 10); err != nil {
+			t.Fatalf("wait err: %v", err)
+		}
+
+		l.mu.Lock()
+		want := l.next
+		l.mu.Unlock()
+
+		// events of canceled wait are released
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := l.wait(ctx, 1000); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("wait of canceled ctx got: %v want: %v", err, context.DeadlineExceeded)
+		}
+
+		l.mu.Lock()
+		got := l.next
+		l.mu.Unlock()
+		if !got.Equal(want) {
+			t.Fatalf("next event got: %v want: %v", got, want)
+		}
+	})
+}
+
+func TestAdaptiveBatch(t *testing.T) {
+	a := newAdaptiveBatch(100*time.Millisecond, 10, 1000, 5000)
+	if size := a.current(); size != 1000 {
+		t.Fatalf("size clamped to max got: %d want: 1000", size)
+	}
+
+	for _, test := range []struct {
+		name string
+		d    time.Duration
+		err  error
+		want int
+	}{
+		{"within target", 110 * time.Millisecond, nil, 1000},
+		{"twice target", 200 * time.Millisecond, nil, 500},
+		{"error", 10 * time.Millisecond, errors.New("failed"), 250},
+		{"below target", 10 * time.Millisecond, nil, 313},
+		{"far above target", time.Hour, nil, 10},
+	} {
+		a.observe(test.d, test.err)
+		if size := a.current(); size != test.want {
+			t.Fatalf("size after %s got: %d want: %d", test.name, size, test.want)
+		}
+	}
+}
+
+func TestAdaptiveBatchSize(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		target time.Duration
+		want   int
+	}{
+		{"shrink", time.Nanosecond, 5},
+		{"grow", time.Hour, 40},
+	} {
+		var sizes []int
+		gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(10), WithAdaptiveBatchSize(test.target, 5, 40),
+			WithProgress(func(progress Progress) { sizes = append(sizes, progress.Rows) }))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(200),
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+		gob.Close()
+
+		if last := sizes[len(sizes)-1]; last != 200 {
+			t.Fatalf("%s rows of last batch got: %d want: 200", test.name, last)
+		}
+
+		// last batch holds remaining rows
+		if size := sizes[len(sizes)-2] - sizes[len(sizes)-3]; size != test.want {
+			t.Fatalf("%s size of batch before last got: %d want: %d", test.name, size, test.want)
+		}
+	}
+
+	for _, option := range []Option{
+		WithAdaptiveBatchSize(0, 5, 40),
+		WithAdaptiveBatchSize(time.Second, 0, 40),
+		WithAdaptiveBatchSize(time.Second, 40, 5),
+	} {
+		if _, err := New(WithDBProvider(DBProviderMemory), option); err == nil {
+			t.Fatalf("init gob with invalid adaptive batch size; want err")
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(10), WithRateLimit(100, 0))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	t0 := time.Now()
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           testGenStudentRowsMySQL(30),
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	if elapsed := time.Since(t0); elapsed < 190*time.Millisecond {
+		t.Fatalf("elapsed of 30 rows at 100 rows/s got: %v want: >= 200ms", elapsed)
+	}
+
+	if _, err := New(WithDBProvider(DBProviderMemory), WithRateLimit(-1, 0)); err == nil {
+		t.Fatalf("init gob with negative rate limit; want err")
+	}
+}
+
+func TestRateLimitSQLite(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup SQLite err: %v", err)
+	}
+
+	gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr), WithBatchSize(1), WithRateLimit(0, 20))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	rows := testGenStudentRowsSQLite(4)
+	t0 := time.Now()
+	if err := gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           rows,
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}); err != nil {
+		t.Fatalf("upsert rows err: %v", err)
+	}
+
+	// fourth statement waits for three statements before
+	if elapsed := time.Since(t0); elapsed < 140*time.Millisecond {
+		t.Fatalf("elapsed of 4 statements at 20 statements/s got: %v want: >= 150ms", elapsed)
+	}
+
+	testVerifyStudentRowsSQLite(t, rows)
+}
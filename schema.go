@@ -0,0 +1,334 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/csmadhu/gob/utils"
+	"github.com/gocql/gocql"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(gocql.UUID{})
+)
+
+// inferType returns column type of Go value
+func inferType(value interface{}) columnType {
+	if value == nil {
+		return columnType{kind: kindAny}
+	}
+
+	return inferReflectType(reflect.TypeOf(value))
+}
+
+func inferReflectType(typ reflect.Type) columnType {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ {
+	case timeType:
+		return columnType{kind: kindTime}
+	case uuidType:
+		return columnType{kind: kindUUID}
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return columnType{kind: kindString}
+	case reflect.Bool:
+		return columnType{kind: kindBool}
+	case reflect.Int8, reflect.Uint8:
+		return columnType{kind: kindInt, bits: 8}
+	case reflect.Int16, reflect.Uint16:
+		return columnType{kind: kindInt, bits: 16}
+	case reflect.Int32, reflect.Uint32:
+		return columnType{kind: kindInt, bits: 32}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return columnType{kind: kindInt, bits: 64}
+	case reflect.Float32, reflect.Float64:
+		return columnType{kind: kindFloat}
+	case reflect.Struct:
+		return columnType{kind: kindJSON}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return columnType{kind: kindBytes}
+		}
+
+		elem := inferReflectType(typ.Elem())
+		return columnType{kind: kindList, elem: &elem}
+	case reflect.Map:
+		key, elem := inferReflectType(typ.Key()), inferReflectType(typ.Elem())
+		return columnType{kind: kindMap, key: &key, elem: &elem}
+	}
+
+	return columnType{kind: kindAny}
+}
+
+// mergeType returns column type able to hold values of a and b
+func mergeType(a, b columnType) columnType {
+	switch {
+	case a.kind == kindAny:
+		return b
+	case b.kind == kindAny:
+		return a
+	case a.kind == kindInt && b.kind == kindInt:
+		if b.bits > a.bits {
+			return b
+		}
+		return a
+	case a.kind == kindInt && b.kind == kindFloat, a.kind == kindFloat && b.kind == kindInt:
+		return columnType{kind: kindFloat}
+	case a.kind == b.kind && a.kind != kindList && a.kind != kindMap:
+		return a
+	case a.kind == b.kind && reflect.DeepEqual(a, b):
+		return a
+	}
+
+	return columnType{kind: kindString}
+}
+
+// schemaColumn of model inferred from rows
+type schemaColumn struct {
+	name string
+	typ  columnType
+	key  bool // column is part of primary key
+}
+
+// modelSchema inferred from rows
+type modelSchema struct {
+	model         string
+	columns       []schemaColumn // key columns in key order followed by columns in sorted order
+	keys          []string       // primary key columns
+	partitionKeys []string       // partition key columns (Cassandra)
+}
+
+// inferModelSchema returns schema of model with column types inferred from first sampleSize rows
+func inferModelSchema(args UpsertArgs, sampleSize int) (modelSchema, error) {
+	schema := modelSchema{model: args.Model}
+	if args.Model == "" {
+		return schema, ErrEmptyModel
+	}
+
+	if len(args.Rows) == 0 {
+		return schema, ErrEmptyRows
+	}
+
+	// keys in order of caller
+	keySet := utils.NewStringSet()
+	for _, key := range args.Keys {
+		if !keySet.Contains(key) {
+			keySet.Insert(key)
+			schema.keys = append(schema.keys, key)
+		}
+	}
+
+	if len(schema.keys) == 0 {
+		return schema, ErrEmptykeys
+	}
+
+	schema.partitionKeys = args.PartitionKeys
+	if len(schema.partitionKeys) == 0 {
+		schema.partitionKeys = schema.keys[:1]
+	}
+
+	for _, key := range schema.partitionKeys {
+		if !keySet.Contains(key) {
+			return schema, fmt.Errorf("%w partition key %s not found in keys", ErrInvalidConflictTarget, key)
+		}
+	}
+
+	rows := args.Rows
+	if sampleSize > 0 && len(rows) > sampleSize {
+		rows = rows[:sampleSize]
+	}
+
+	types := make(map[string]columnType)
+	columnSet := utils.NewStringSet()
+	for _, row := range rows {
+		for _, column := range row.Columns() {
+			columnSet.Insert(column)
+			types[column] = mergeType(types[column], inferType(row.Value(column)))
+		}
+	}
+
+	for _, key := range schema.keys {
+		schema.columns = append(schema.columns, schemaColumn{name: key, typ: types[key], key: true})
+	}
+
+	for _, column := range columnSet.Difference(keySet).ToSlice() {
+		schema.columns = append(schema.columns, schemaColumn{name: column, typ: types[column]})
+	}
+
+	return schema, nil
+}
+
+// CreateModelDDL returns statement of provider to create model with column types inferred
+// from first sampleSize rows and primary key of Keys; all rows are sampled if sampleSize is zero
+func CreateModelDDL(provider DBProvider, args UpsertArgs, sampleSize int) (string, error) {
+	schema, err := inferModelSchema(args, sampleSize)
+	if err != nil {
+		return "", err
+	}
+
+	switch provider {
+	case DBProviderPg, DBProviderCockroach, DBProviderYugabyte:
+		return pgCreateModelSQL(schema), nil
+	case DBProviderMySQL, DBProviderMariaDB, DBProviderTiDB:
+		return mysqlCreateModelSQL(schema), nil
+	case DBProviderCassandra:
+		return cassyCreateModelSQL(schema), nil
+	case DBProviderSQLite:
+		return sqliteCreateModelSQL(schema), nil
+	case DBProviderMSSQL:
+		return mssqlCreateModelSQL(schema), nil
+	}
+
+	return "", fmt.Errorf("gob: invalid dbProvider: %s", provider)
+}
+
+// createModelIfNotFound creates model from rows if not found in database
+func (gob *Gob) createModelIfNotFound(ctx context.Context, args UpsertArgs) error {
+	_, err := gob.Metadata(ctx, args.Model)
+	if !errors.Is(err, ErrModelNotFound) {
+		return err
+	}
+
+	provider := gob.getProvider()
+	// conn closed
+	if provider == nil {
+		return ErrConnClosed
+	}
+
+	creator, ok := provider.(ModelCreator)
+	if !ok {
+		return fmt.Errorf("%w create model '%s' with provider %s", ErrUnsupported, args.Model, gob.dbProvider)
+	}
+
+	if err := creator.CreateModel(ctx, args, gob.createModelSampleSize); err != nil {
+		return err
+	}
+
+	_, err = gob.loadMetadata(ctx, args.Model)
+	return err
+}
+
+// unknownColumns returns columns of rows not found in model with first non nil value of each column
+func unknownColumns(metadata *Metadata, rows []Row) (columns []string, values map[string]interface{}) {
+	var (
+		known = metadata.columnSet()
+		found = make(map[string]bool)
+	)
+
+	values = make(map[string]interface{})
+	for _, row := range rows {
+		for _, column := range row.Columns() {
+			if _, ok := known[column]; ok {
+				continue
+			}
+
+			if !found[column] {
+				found[column] = true
+				columns = append(columns, column)
+			}
+
+			if values[column] == nil {
+				values[column] = row.Value(column)
+			}
+		}
+	}
+
+	return columns, values
+}
+
+// applyUnknownColumnPolicy handles columns of rows not found in model;
+// returns metadata refreshed after adding columns
+func (gob *Gob) applyUnknownColumnPolicy(ctx context.Context, policy UnknownColumnPolicy, metadata *Metadata, rows []Row, offset int) (*Metadata, []Row, error) {
+	columns, values := unknownColumns(metadata, rows)
+	if len(columns) == 0 {
+		return metadata, rows, nil
+	}
+
+	switch policy {
+	case UnknownColumnDrop:
+		return metadata, dropColumns(rows, columns), nil
+	case UnknownColumnAdd:
+		metadata, err := gob.addColumns(ctx, metadata.Model, columns, values)
+		return metadata, rows, err
+	}
+
+	var rowErrs []RowError
+	for idx, row := range rows {
+		var problems []string
+		for _, column := range columns {
+			if _, ok := row[column]; ok {
+				problems = append(problems, fmt.Sprintf("column '%s' not found in model", column))
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrs = append(rowErrs, RowError{Index: offset + idx, Problems: problems})
+		}
+	}
+
+	return nil, nil, &ValidationError{Model: metadata.Model, Rows: rowErrs}
+}
+
+// dropColumns returns rows without columns; rows of caller are not modified
+func dropColumns(rows []Row, columns []string) []Row {
+	result := make([]Row, len(rows))
+	for idx, row := range rows {
+		var dropped Row
+		for _, column := range columns {
+			if _, ok := row[column]; !ok {
+				continue
+			}
+
+			if dropped == nil {
+				dropped = copyRow(row)
+			}
+			delete(dropped, column)
+		}
+
+		result[idx] = row
+		if dropped != nil {
+			result[idx] = dropped
+		}
+	}
+
+	return result
+}
+
+// addColumns alters model to add columns and reloads metadata
+func (gob *Gob) addColumns(ctx context.Context, model string, columns []string, values map[string]interface{}) (*Metadata, error) {
+	provider := gob.getProvider()
+	// conn closed
+	if provider == nil {
+		return nil, ErrConnClosed
+	}
+
+	adder, ok := provider.(ColumnAdder)
+	if !ok {
+		return nil, fmt.Errorf("%w add columns to model '%s' with provider %s", ErrUnsupported, model, gob.dbProvider)
+	}
+
+	for _, column := range columns {
+		if err := adder.AddColumn(ctx, model, column, values[column]); err != nil {
+			// column may be added concurrently
+			metadata, refreshErr := gob.loadMetadata(ctx, model)
+			if refreshErr != nil {
+				return nil, err
+			}
+
+			if _, ok := metadata.columnSet()[column]; !ok {
+				return nil, err
+			}
+		}
+	}
+
+	return gob.loadMetadata(ctx, model)
+}
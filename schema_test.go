@@ -0,0 +1,209 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInferType(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		pg     string
+		mysql  string
+		cassy  string
+		sqlite string
+		mssql  string
+	}{
+		{name: "nil", value: nil, pg: "text", mysql: "TEXT", cassy: "text", sqlite: "TEXT", mssql: "NVARCHAR(MAX)"},
+		{name: "string", value: "name-0", pg: "text", mysql: "TEXT", cassy: "text", sqlite: "TEXT", mssql: "NVARCHAR(MAX)"},
+		{name: "int", value: 1, pg: "bigint", mysql: "BIGINT", cassy: "bigint", sqlite: "INTEGER", mssql: "BIGINT"},
+		{name: "int32", value: int32(1), pg: "integer", mysql: "INT", cassy: "int", sqlite: "INTEGER", mssql: "INT"},
+		{name: "int8", value: int8(1), pg: "smallint", mysql: "TINYINT", cassy: "tinyint", sqlite: "INTEGER", mssql: "SMALLINT"},
+		{name: "float", value: 1.5, pg: "double precision", mysql: "DOUBLE", cassy: "double", sqlite: "REAL", mssql: "FLOAT"},
+		{name: "bool", value: true, pg: "boolean", mysql: "BOOLEAN", cassy: "boolean", sqlite: "BOOLEAN", mssql: "BIT"},
+		{name: "time", value: time.Now(), pg: "timestamp with time zone", mysql: "DATETIME(6)", cassy: "timestamp", sqlite: "TIMESTAMP", mssql: "DATETIME2"},
+		{name: "bytes", value: []byte("name-0"), pg: "bytea", mysql: "BLOB", cassy: "blob", sqlite: "BLOB", mssql: "VARBINARY(MAX)"},
+		{name: "struct", value: studentProfile{}, pg: "jsonb", mysql: "JSON", cassy: "text", sqlite: "JSON", mssql: "NVARCHAR(MAX)"},
+		{name: "list", value: []string{"english"}, pg: "text[]", mysql: "JSON", cassy: "list<text>", sqlite: "JSON", mssql: "NVARCHAR(MAX)"},
+		{name: "nestedList", value: [][]int{{1}}, pg: "jsonb", mysql: "JSON", cassy: "list<frozen<list<bigint>>>", sqlite: "JSON", mssql: "NVARCHAR(MAX)"},
+		{name: "map", value: map[string]int32{}, pg: "jsonb", mysql: "JSON", cassy: "map<text, int>", sqlite: "JSON", mssql: "NVARCHAR(MAX)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			typ := inferType(test.value)
+			if got := pgTypeName(typ); got != test.pg {
+				t.Fatalf("PostgreSQL type got: %s want: %s", got, test.pg)
+			}
+
+			if got := mysqlTypeName(typ, false); got != test.mysql {
+				t.Fatalf("MySQL type got: %s want: %s", got, test.mysql)
+			}
+
+			if got := cassyTypeName(typ); got != test.cassy {
+				t.Fatalf("Cassandra type got: %s want: %s", got, test.cassy)
+			}
+
+			if got := sqliteTypeName(typ); got != test.sqlite {
+				t.Fatalf("SQLite type got: %s want: %s", got, test.sqlite)
+			}
+
+			if got := mssqlTypeName(typ, false); got != test.mssql {
+				t.Fatalf("SQL Server type got: %s want: %s", got, test.mssql)
+			}
+		})
+	}
+}
+
+func TestUnknownColumnPolicy(t *testing.T) {
+	rows := []Row{
+		{"id": "7f4df5a4-25c0-11eb-adc1-0242ac120002", "name": "name-0", "grade": nil},
+		{"id": "7f4df5a4-25c0-11eb-adc1-0242ac120002", "name": "name-1", "grade": 3, "house": "red"},
+		{"id": "7f4df5a4-25c0-11eb-adc1-0242ac120002", "name": "name-2"},
+	}
+
+	t.Run("unknownColumns", func(t *testing.T) {
+		columns, values := unknownColumns(testStudentsMetadata, rows)
+		if want := []string{"grade", "house"}; !reflect.DeepEqual(columns, want) {
+			t.Fatalf("columns got: %v want: %v", columns, want)
+		}
+
+		if want := map[string]interface{}{"grade": 3, "house": "red"}; !reflect.DeepEqual(values, want) {
+			t.Fatalf("values got: %v want: %v", values, want)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		gob := defaultGob()
+		_, got, err := gob.applyUnknownColumnPolicy(context.Background(), UnknownColumnDrop, testStudentsMetadata, rows, 0)
+		if err != nil {
+			t.Fatalf("apply policy err: %v", err)
+		}
+
+		for idx, row := range got {
+			if row.Len() != 2 {
+				t.Fatalf("row %d got: %v want: columns id, name", idx, row)
+			}
+		}
+
+		if rows[1].Len() != 4 {
+			t.Fatalf("row of caller modified: %v", rows[1])
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		gob := defaultGob()
+		_, _, err := gob.applyUnknownColumnPolicy(context.Background(), UnknownColumnError, testStudentsMetadata, rows, 5)
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("apply policy got: %v want: %v", err, ErrInvalidRows)
+		}
+
+		want := []RowError{
+			{Index: 5, Problems: []string{"column 'grade' not found in model"}},
+			{Index: 6, Problems: []string{"column 'grade' not found in model", "column 'house' not found in model"}},
+		}
+
+		if !reflect.DeepEqual(validationErr.Rows, want) {
+			t.Fatalf("row errors got: %+v want: %+v", validationErr.Rows, want)
+		}
+	})
+
+	t.Run("invalidPolicy", func(t *testing.T) {
+		if err := WithUnknownColumnPolicy("students", "ignore")(defaultGob()); err == nil {
+			t.Fatalf("invalid policy; want err")
+		}
+
+		if err := WithUnknownColumnPolicy("", UnknownColumnDrop)(defaultGob()); !errors.Is(err, ErrEmptyModel) {
+			t.Fatalf("empty model got: %v want: %v", err, ErrEmptyModel)
+		}
+	})
+}
+
+func TestCreateModelDDL(t *testing.T) {
+	rows := []Row{
+		{"name": "name-0", "class": 1, "age": int32(10), "score": 1, "birthday": nil},
+		{"name": "name-1", "class": 1, "age": nil, "score": 1.5, "birthday": time.Now(), "subjects": []string{"english"}},
+		{"name": "name-2", "class": 2, "nickname": "foo"},
+	}
+
+	args := UpsertArgs{Model: "students", Keys: []string{"name", "class", "name"}, Rows: rows}
+
+	tests := []struct {
+		provider DBProvider
+		args     UpsertArgs
+		want     string
+	}{
+		{
+			provider: DBProviderPg,
+			args:     args,
+			want:     "CREATE TABLE IF NOT EXISTS students (name text NOT NULL, class bigint NOT NULL, age integer, birthday timestamp with time zone, score double precision, subjects text[], PRIMARY KEY (name, class))",
+		},
+		{
+			provider: DBProviderCockroach,
+			args:     args,
+			want:     "CREATE TABLE IF NOT EXISTS students (name text NOT NULL, class bigint NOT NULL, age integer, birthday timestamp with time zone, score double precision, subjects text[], PRIMARY KEY (name, class))",
+		},
+		{
+			provider: DBProviderMySQL,
+			args:     args,
+			want:     "CREATE TABLE IF NOT EXISTS students (name VARCHAR(255) NOT NULL, class BIGINT NOT NULL, age INT, birthday DATETIME(6), score DOUBLE, subjects JSON, PRIMARY KEY (name, class))",
+		},
+		{
+			provider: DBProviderCassandra,
+			args:     args,
+			want:     "CREATE TABLE IF NOT EXISTS students (name text, class bigint, age int, birthday timestamp, score double, subjects list<text>, PRIMARY KEY (name, class))",
+		},
+		{
+			provider: DBProviderCassandra,
+			args:     UpsertArgs{Model: "students", Keys: []string{"name", "class", "age"}, PartitionKeys: []string{"name", "class"}, Rows: rows},
+			want:     "CREATE TABLE IF NOT EXISTS students (name text, class bigint, age int, birthday timestamp, score double, subjects list<text>, PRIMARY KEY ((name, class), age))",
+		},
+		{
+			provider: DBProviderSQLite,
+			args:     args,
+			want:     "CREATE TABLE IF NOT EXISTS students (name TEXT NOT NULL, class INTEGER NOT NULL, age INTEGER, birthday TIMESTAMP, score REAL, subjects JSON, PRIMARY KEY (name, class))",
+		},
+		{
+			provider: DBProviderMSSQL,
+			args:     args,
+			want:     "IF OBJECT_ID('students', 'U') IS NULL CREATE TABLE students (name NVARCHAR(450) NOT NULL, class BIGINT NOT NULL, age INT, birthday DATETIME2, score FLOAT, subjects NVARCHAR(MAX), PRIMARY KEY (name, class))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.provider), func(t *testing.T) {
+			got, err := CreateModelDDL(test.provider, test.args, 2)
+			if err != nil {
+				t.Fatalf("create model DDL err: %v", err)
+			}
+
+			if got != test.want {
+				t.Fatalf("DDL got: %s want: %s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := CreateModelDDL(DBProviderPg, UpsertArgs{Model: "students", Rows: rows}, 2); !errors.Is(err, ErrEmptykeys) {
+			t.Fatalf("error got: %v want: %v", err, ErrEmptykeys)
+		}
+
+		if _, err := CreateModelDDL(DBProviderPg, UpsertArgs{Model: "students", Keys: []string{"name"}}, 2); !errors.Is(err, ErrEmptyRows) {
+			t.Fatalf("error got: %v want: %v", err, ErrEmptyRows)
+		}
+
+		if _, err := CreateModelDDL(DBProviderCassandra, UpsertArgs{Model: "students", Keys: []string{"name"}, PartitionKeys: []string{"class"}, Rows: rows}, 2); !errors.Is(err, ErrInvalidConflictTarget) {
+			t.Fatalf("error got: %v want: %v", err, ErrInvalidConflictTarget)
+		}
+
+		if _, err := CreateModelDDL("test", args, 2); err == nil {
+			t.Fatalf("invalid provider; want err")
+		}
+	})
+}
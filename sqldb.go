@@ -0,0 +1,271 @@
+package gob
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dialect renders statements of a database/sql driver for the generic SQL provider
+type Dialect interface {
+	// DriverName of database/sql driver e.g. mysql
+	DriverName() string
+
+	// Placeholder of nth argument of statement starting at 1 e.g. ? or $1
+	Placeholder(n int) string
+
+	// Quote identifier e.g. `name`
+	Quote(identifier string) string
+
+	// UpsertSQL returns statement inserting rows into model resolving conflicts on keys with conflict action;
+	// values are the placeholders of each row e.g. (?,?) for unquoted columns in the same order.
+	// Returns ErrInvalidConflictTarget or ErrEmptykeys if conflict target of args is not supported
+	UpsertSQL(args UpsertArgs, columns []string, values []string) (string, error)
+
+	// MaxArgs is the maximum number of arguments of a statement; rows are split across statements to fit, 0 for no limit
+	MaxArgs() int
+}
+
+// sqlDB upserts rows with any database/sql driver using statements rendered by dialect
+type sqlDB struct {
+	*sql.DB
+	dialect  Dialect
+	name     string // name of database in errors
+	observer observer
+}
+
+// NewSQLProvider connects to database with database/sql driver of dialect
+func NewSQLProvider(args ConnArgs, dialect Dialect) (Provider, error) {
+	return newSQLDB(args, dialect, dialect.DriverName())
+}
+
+// SQLFactory returns factory of generic SQL provider with dialect to Register
+func SQLFactory(dialect Dialect) Factory {
+	return func(args ConnArgs) (Provider, error) {
+		return NewSQLProvider(args, dialect)
+	}
+}
+
+func newSQLDB(args ConnArgs, dialect Dialect, name string) (*sqlDB, error) {
+	conn, err := sql.Open(dialect.DriverName(), args.ConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("gob: connect to %s: %w", name, err)
+	}
+
+	if err := conn.Ping(); err != nil {
+		return nil, fmt.Errorf("gob: ping to %s: %w", name, err)
+	}
+
+	conn.SetConnMaxIdleTime(args.ConnIdleTime)
+	conn.SetConnMaxLifetime(args.ConnLifeTime)
+	conn.SetMaxIdleConns(args.IdleConns)
+	conn.SetMaxOpenConns(args.OpenConns)
+
+	return &sqlDB{DB: conn, dialect: dialect, name: name, observer: newObserver(args, name)}, nil
+}
+
+func (db *sqlDB) Close() {
+	db.DB.Close()
+}
+
+func (db *sqlDB) exec(ctx context.Context, sql string) error {
+	if _, err := db.ExecContext(ctx, sql); err != nil {
+		return fmt.Errorf("gob: execute sql '%s' on %s: %w", sql, db.name, err)
+	}
+
+	return nil
+}
+
+func (db *sqlDB) Upsert(ctx context.Context, upsertArgs UpsertArgs) error {
+	// render statements before starting the transaction
+	statements, err := db.Build(upsertArgs)
+	if err != nil {
+		return err
+	}
+
+	statements = append(statements, upsertArgs.extra...)
+	if len(statements) == 0 {
+		return nil
+	}
+
+	return db.upsertTx(ctx, upsertArgs, statements)
+}
+
+// upsertTx executes statements of batch in a transaction
+func (db *sqlDB) upsertTx(ctx context.Context, upsertArgs UpsertArgs, statements []Statement) error {
+	// wait before transaction to not hold locks
+	if err := db.observer.wait(ctx, len(statements)); err != nil {
+		return err
+	}
+
+	// start transaction
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("gob: begin %s tx: %w", db.name, err)
+	}
+
+	for _, statement := range statements {
+		stmtCtx, done := db.observer.statementContext(ctx)
+		t0 := time.Now()
+		_, err := tx.ExecContext(stmtCtx, statement.SQL, statement.Args...)
+		db.observer.statement(ctx, statement.SQL, t0)
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return done(fmt.Errorf("gob: execute upsert sql '%s' on %s: %w rollback tx: %v", statement.SQL, db.name, err, rollbackErr))
+			}
+			return done(fmt.Errorf("gob: execute upsert sql '%s' on %s: %w", statement.SQL, db.name, err))
+		}
+		done(nil)
+	}
+
+	// commit transaction
+	_, span := db.observer.commit(ctx, upsertArgs.Model, len(upsertArgs.Rows))
+	err = tx.Commit()
+	endSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("gob: commit %s tx: %w", db.name, err)
+	}
+
+	return nil
+}
+
+// Build statements of rows of batch
+func (db *sqlDB) Build(upsertArgs UpsertArgs) ([]Statement, error) {
+	return sqlStatements(db.dialect, upsertArgs)
+}
+
+// sqlStatements renders consecutive rows with the same columns as multi-row statements within MaxArgs of dialect
+func sqlStatements(dialect Dialect, upsertArgs UpsertArgs) ([]Statement, error) {
+	var (
+		statements []Statement
+		columns    []string
+		rows       []Row
+	)
+
+	for _, row := range upsertArgs.Rows {
+		if row.Len() == 0 {
+			continue // ignore empty row
+		}
+
+		rowColumns := row.Columns()
+		maxRows := sqlMaxRows(dialect, columns)
+		if len(rows) > 0 && (!equalColumns(rowColumns, columns) || (maxRows > 0 && len(rows) >= maxRows)) {
+			statement, err := sqlRowsStatement(dialect, upsertArgs, columns, rows)
+			if err != nil {
+				return nil, err
+			}
+
+			statements = append(statements, statement)
+			rows = nil
+		}
+
+		columns = rowColumns
+		rows = append(rows, row)
+	}
+
+	if len(rows) > 0 {
+		statement, err := sqlRowsStatement(dialect, upsertArgs, columns, rows)
+		if err != nil {
+			return nil, err
+		}
+
+		statements = append(statements, statement)
+	}
+
+	return statements, nil
+}
+
+// sqlMaxRows returns maximum number of rows with columns in a statement; 0 for no limit
+func sqlMaxRows(dialect Dialect, columns []string) int {
+	maxArgs := dialect.MaxArgs()
+	if maxArgs <= 0 || len(columns) == 0 {
+		return 0
+	}
+
+	if maxRows := maxArgs / len(columns); maxRows > 1 {
+		return maxRows
+	}
+
+	return 1
+}
+
+func sqlRowsStatement(dialect Dialect, upsertArgs UpsertArgs, columns []string, rows []Row) (Statement, error) {
+	var (
+		statement Statement
+		values    []string
+		count     = 1
+	)
+
+	for _, row := range rows {
+		var placeholders []string
+		for _, column := range columns {
+			placeholders = append(placeholders, dialect.Placeholder(count))
+			statement.Args = append(statement.Args, row.Value(column))
+			count = count + 1
+		}
+
+		values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+	}
+
+	sql, err := dialect.UpsertSQL(upsertArgs, columns, values)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	statement.SQL = sql
+	return statement, nil
+}
+
+func equalColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// quoteColumns returns columns quoted by dialect
+func quoteColumns(dialect Dialect, columns []string) []string {
+	quoted := make([]string, len(columns))
+	for idx, column := range columns {
+		quoted[idx] = dialect.Quote(column)
+	}
+
+	return quoted
+}
+
+func (db *sqlDB) queryRow(ctx context.Context, sql string, args ...interface{}) rowScanner {
+	return db.QueryRowContext(ctx, sql, args...)
+}
+
+func (db *sqlDB) Ping(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("gob: ping %s: %w", db.name, err)
+	}
+
+	return nil
+}
+
+func (db *sqlDB) placeholder(n int) string {
+	return db.dialect.Placeholder(n)
+}
+
+// typeName of column type of dialects of gob; types of other dialects are unknown
+func (db *sqlDB) typeName(typ columnType) string {
+	switch db.dialect.(type) {
+	case mysqlDialect:
+		return mysqlTypeName(typ, false)
+	case sqliteDialect:
+		return sqliteTypeName(typ)
+	}
+
+	return ""
+}
@@ -0,0 +1,94 @@
+package gob
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testDialectRowToSQL renders statement of row with dialect for testRowToSQL
+func testDialectRowToSQL(dialect Dialect) func(Row, UpsertArgs) (string, []interface{}) {
+	return func(row Row, upsertArgs UpsertArgs) (string, []interface{}) {
+		upsertArgs.Rows = []Row{row}
+		statements, err := sqlStatements(dialect, upsertArgs)
+		if err != nil || len(statements) != 1 {
+			return "", nil
+		}
+
+		return statements[0].SQL, statements[0].Args
+	}
+}
+
+// testDialect renders numbered placeholders with at most maxArgs arguments
+type testDialect struct {
+	maxArgs int
+}
+
+func (testDialect) DriverName() string             { return "test" }
+func (testDialect) Placeholder(n int) string       { return fmt.Sprintf("$%d", n) }
+func (testDialect) Quote(identifier string) string { return identifier }
+func (d testDialect) MaxArgs() int                 { return d.maxArgs }
+func (testDialect) UpsertSQL(args UpsertArgs, columns []string, values []string) (string, error) {
+	return fmt.Sprintf("%s%v%v", args.Model, columns, values), nil
+}
+
+func TestSQLStatements(t *testing.T) {
+	rows := []Row{
+		{"name": "name-0", "age": 0},
+		{"name": "name-1", "age": 1},
+		{},
+		{"name": "name-2", "age": 2},
+		{"name": "name-3"},
+		{"name": "name-4", "age": 4},
+	}
+
+	tests := []struct {
+		name    string
+		maxArgs int
+		want    []Statement
+	}{
+		{
+			name:    "unlimited",
+			maxArgs: 0,
+			want: []Statement{
+				{SQL: "students[age name][($1,$2) ($3,$4) ($5,$6)]", Args: []interface{}{0, "name-0", 1, "name-1", 2, "name-2"}},
+				{SQL: "students[name][($1)]", Args: []interface{}{"name-3"}},
+				{SQL: "students[age name][($1,$2)]", Args: []interface{}{4, "name-4"}},
+			},
+		},
+		{
+			name:    "maxArgs",
+			maxArgs: 5,
+			want: []Statement{
+				{SQL: "students[age name][($1,$2) ($3,$4)]", Args: []interface{}{0, "name-0", 1, "name-1"}},
+				{SQL: "students[age name][($1,$2)]", Args: []interface{}{2, "name-2"}},
+				{SQL: "students[name][($1)]", Args: []interface{}{"name-3"}},
+				{SQL: "students[age name][($1,$2)]", Args: []interface{}{4, "name-4"}},
+			},
+		},
+		{
+			name:    "maxArgsBelowColumns",
+			maxArgs: 1,
+			want: []Statement{
+				{SQL: "students[age name][($1,$2)]", Args: []interface{}{0, "name-0"}},
+				{SQL: "students[age name][($1,$2)]", Args: []interface{}{1, "name-1"}},
+				{SQL: "students[age name][($1,$2)]", Args: []interface{}{2, "name-2"}},
+				{SQL: "students[name][($1)]", Args: []interface{}{"name-3"}},
+				{SQL: "students[age name][($1,$2)]", Args: []interface{}{4, "name-4"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := sqlStatements(testDialect{maxArgs: test.maxArgs}, UpsertArgs{Model: "students", Rows: rows})
+			if err != nil {
+				t.Fatalf("statements err: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("statements got: %+v want: %+v", got, test.want)
+			}
+		})
+	}
+}
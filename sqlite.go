@@ -0,0 +1,349 @@
+package gob
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/csmadhu/gob/internal/hooks"
+)
+
+type sqlite struct {
+	*sqlDB
+}
+
+// sqliteRowError reports whether err is caused by values of a row: constraint violations and datatype mismatches;
+// set with the driver by package github.com/csmadhu/gob/sqlite
+var sqliteRowError = func(err error) bool { return false }
+
+// provider of SQLite is registered by package github.com/csmadhu/gob/sqlite importing the cgo driver
+func init() {
+	hooks.RegisterSQLite = func(rowError func(err error) bool) {
+		sqliteRowError = rowError
+		Register(DBProviderSQLite, newSQLite)
+	}
+}
+
+func newSQLite(args ConnArgs) (Provider, error) {
+	db, err := newSQLDB(args, DialectSQLite, "SQLite")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows single writer at a time
+	db.SetMaxOpenConns(1)
+
+	return &sqlite{db}, nil
+}
+
+func (db *sqlite) Metadata(ctx context.Context, model string) (*Metadata, error) {
+	metadata := &Metadata{Model: model}
+	if err := db.columns(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	if err := db.keys(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// sqlitePragma returns PRAGMA statement of schema qualified model
+func sqlitePragma(pragma, model string) string {
+	schema, table := splitModel(model)
+	if schema != "" {
+		return fmt.Sprintf("PRAGMA %s.%s('%s')", schema, pragma, table)
+	}
+
+	return fmt.Sprintf("PRAGMA %s('%s')", pragma, table)
+}
+
+func (db *sqlite) columns(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	rows, err := db.QueryContext(ctx, sqlitePragma("table_info", model))
+	if err != nil {
+		return fmt.Errorf("gob: query columns of model '%s' on SQLite: %w", model, err)
+	}
+	defer rows.Close()
+
+	var primaryKey = make(map[int]string)
+	for rows.Next() {
+		var (
+			column       Column
+			cid          int
+			notNull      bool
+			defaultValue sql.NullString
+			pk           int
+		)
+
+		if err := rows.Scan(&cid, &column.Name, &column.Type, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("gob: scan columns of model '%s' on SQLite: %w", model, err)
+		}
+
+		column.Nullable = !notNull
+		column.Default = defaultValue.Valid
+		column.typ = sqliteColumnType(column.Type)
+		metadata.Columns = append(metadata.Columns, column)
+
+		if pk > 0 {
+			primaryKey[pk] = column.Name
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("gob: read columns of model '%s' on SQLite: %w", model, err)
+	}
+
+	if len(metadata.Columns) == 0 {
+		return fmt.Errorf("%w '%s' on SQLite", ErrModelNotFound, model)
+	}
+
+	for idx := 1; idx <= len(primaryKey); idx++ {
+		metadata.PrimaryKey = append(metadata.PrimaryKey, primaryKey[idx])
+	}
+
+	return nil
+}
+
+func (db *sqlite) keys(ctx context.Context, metadata *Metadata) error {
+	model := metadata.Model
+	rows, err := db.QueryContext(ctx, sqlitePragma("index_list", model))
+	if err != nil {
+		return fmt.Errorf("gob: query keys of model '%s' on SQLite: %w", model, err)
+	}
+	defer rows.Close()
+
+	var indexes []string
+	for rows.Next() {
+		var (
+			seq     int
+			index   string
+			unique  bool
+			origin  string
+			partial bool
+		)
+
+		if err := rows.Scan(&seq, &index, &unique, &origin, &partial); err != nil {
+			return fmt.Errorf("gob: scan keys of model '%s' on SQLite: %w", model, err)
+		}
+
+		// primary key is read from table_info
+		if unique && !partial && origin != "pk" {
+			indexes = append(indexes, index)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("gob: read keys of model '%s' on SQLite: %w", model, err)
+	}
+
+	rows.Close()
+	for _, index := range indexes {
+		columns, err := db.indexColumns(ctx, index)
+		if err != nil {
+			return fmt.Errorf("gob: read keys of model '%s' on SQLite: %w", model, err)
+		}
+
+		if len(columns) > 0 {
+			metadata.UniqueKeys = append(metadata.UniqueKeys, columns)
+		}
+	}
+
+	return nil
+}
+
+// indexColumns returns columns of index in key order; nil if index has expressions
+func (db *sqlite) indexColumns(ctx context.Context, index string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA index_info('%s')", index))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var (
+			seqno  int
+			cid    int
+			column sql.NullString
+		)
+
+		if err := rows.Scan(&seqno, &cid, &column); err != nil {
+			return nil, err
+		}
+
+		// expressions have no column
+		if !column.Valid {
+			return nil, rows.Err()
+		}
+		columns = append(columns, column.String)
+	}
+
+	return columns, rows.Err()
+}
+
+// sqliteColumnType maps declared type to column type using rules of type affinity
+func sqliteColumnType(typ string) columnType {
+	typ = strings.ToUpper(typ)
+	switch {
+	case strings.Contains(typ, "INT"):
+		return columnType{kind: kindInt, bits: 64}
+	case strings.Contains(typ, "CHAR"), strings.Contains(typ, "CLOB"), strings.Contains(typ, "TEXT"):
+		return columnType{kind: kindString}
+	case strings.Contains(typ, "BLOB"):
+		return columnType{kind: kindBytes}
+	case strings.Contains(typ, "REAL"), strings.Contains(typ, "FLOA"), strings.Contains(typ, "DOUB"):
+		return columnType{kind: kindFloat}
+	case strings.HasPrefix(typ, "BOOL"):
+		return columnType{kind: kindBool}
+	case strings.HasPrefix(typ, "DATETIME"), strings.HasPrefix(typ, "TIMESTAMP"):
+		return columnType{kind: kindTime}
+	case typ == "DATE":
+		return columnType{kind: kindDate}
+	case typ == "JSON":
+		return columnType{kind: kindJSON}
+	}
+
+	return columnType{kind: kindAny}
+}
+
+// sqliteTypeName returns SQLite type of column type
+func sqliteTypeName(typ columnType) string {
+	switch typ.kind {
+	case kindInt:
+		return "INTEGER"
+	case kindFloat:
+		return "REAL"
+	case kindBool:
+		return "BOOLEAN"
+	case kindTime:
+		return "TIMESTAMP"
+	case kindDate:
+		return "DATE"
+	case kindBytes:
+		return "BLOB"
+	case kindJSON, kindList, kindMap:
+		return "JSON"
+	}
+
+	return "TEXT"
+}
+
+func (db *sqlite) AddColumn(ctx context.Context, model, column string, value interface{}) error {
+	return db.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", model, column, sqliteTypeName(inferType(value))))
+}
+
+// sqliteCreateModelSQL returns CREATE TABLE statement of schema
+func sqliteCreateModelSQL(schema modelSchema) string {
+	var defs []string
+	for _, column := range schema.columns {
+		def := fmt.Sprintf("%s %s", column.name, sqliteTypeName(column.typ))
+		if column.key {
+			def = def + " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s))",
+		schema.model,
+		strings.Join(defs, ", "),
+		strings.Join(schema.keys, ", "),
+	)
+}
+
+func (db *sqlite) CreateModel(ctx context.Context, args UpsertArgs, sampleSize int) error {
+	schema, err := inferModelSchema(args, sampleSize)
+	if err != nil {
+		return err
+	}
+
+	return db.exec(ctx, sqliteCreateModelSQL(schema))
+}
+
+// validateSQLiteConflictTarget verifies ON CONFLICT target before starting the transaction
+func validateSQLiteConflictTarget(upsertArgs UpsertArgs) error {
+	if upsertArgs.Constraint != "" {
+		return fmt.Errorf("%w constraint not supported by SQLite", ErrInvalidConflictTarget)
+	}
+
+	if len(upsertArgs.Keys) == 0 {
+		return ErrEmptykeys
+	}
+
+	// SQLite shares syntax of conflict target with PostgreSQL
+	for _, key := range upsertArgs.Keys {
+		if !pgValidKey(key) {
+			return fmt.Errorf("%w invalid key %s", ErrInvalidConflictTarget, key)
+		}
+	}
+
+	if upsertArgs.KeyPredicate != "" && !pgValidExpr(upsertArgs.KeyPredicate) {
+		return fmt.Errorf("%w invalid key predicate %s", ErrInvalidConflictTarget, upsertArgs.KeyPredicate)
+	}
+
+	return nil
+}
+
+// sqliteDialect renders INSERT ... ON CONFLICT statements of SQLite
+type sqliteDialect struct{}
+
+// DialectSQLite renders statements of SQLite
+var DialectSQLite Dialect = sqliteDialect{}
+
+func (sqliteDialect) DriverName() string {
+	return "sqlite3"
+}
+
+func (sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// MaxArgs is SQLITE_MAX_VARIABLE_NUMBER of SQLite prior to 3.32.0
+func (sqliteDialect) MaxArgs() int {
+	return 999
+}
+
+// UpsertSQL updates columns other than keys on conflict of keys; keys may be index expressions and are not quoted
+func (d sqliteDialect) UpsertSQL(args UpsertArgs, columns []string, values []string) (string, error) {
+	if err := validateSQLiteConflictTarget(args); err != nil {
+		return "", err
+	}
+
+	var updateClause []string
+	for _, column := range columns {
+		if !args.IsKey(column) {
+			quoted := d.Quote(column)
+			updateClause = append(updateClause, fmt.Sprintf("%s=excluded.%s", quoted, quoted))
+		}
+	}
+
+	// index inference clause of partial index as ON CONFLICT of PostgreSQL
+	target := fmt.Sprintf("(%s)", strings.Join(args.Keys, ","))
+	if args.KeyPredicate != "" {
+		target = fmt.Sprintf("%s WHERE %s", target, args.KeyPredicate)
+	}
+
+	action := "DO NOTHING"
+	if args.ConflictAction == ConflictActionUpdate && len(updateClause) > 0 {
+		action = fmt.Sprintf("DO UPDATE SET %s", strings.Join(updateClause, ","))
+	}
+
+	return fmt.Sprintf("INSERT INTO %s(%s) VALUES%s ON CONFLICT%s %s",
+		args.Model,
+		strings.Join(quoteColumns(d, columns), ","),
+		strings.Join(values, ","),
+		target,
+		action,
+	), nil
+}
+
+// requiresConflictTarget of ON CONFLICT
+func (db *sqlite) requiresConflictTarget() bool {
+	return true
+}
@@ -0,0 +1,15 @@
+// Package sqlite registers gob.DBProviderSQLite with the cgo driver github.com/mattn/go-sqlite3; import it for its
+// side effect
+//
+//	import _ "github.com/csmadhu/gob/sqlite"
+package sqlite
+
+import (
+	_ "github.com/csmadhu/gob" // sets hooks
+	"github.com/csmadhu/gob/internal/driver/sqlite"
+	"github.com/csmadhu/gob/internal/hooks"
+)
+
+func init() {
+	hooks.RegisterSQLite(sqlite.RowError)
+}
@@ -0,0 +1,20 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/csmadhu/gob"
+)
+
+func TestRegister(t *testing.T) {
+	g, err := gob.New(gob.WithDBProvider(gob.DBProviderSQLite), gob.WithDBConnStr("file:gob?mode=memory&cache=shared"))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer g.Close()
+
+	if err := g.Ping(context.Background()); err != nil {
+		t.Fatalf("ping SQLite err: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package gob
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/csmadhu/gob/utils"
+)
+
+var (
+	testSQLiteDB      *sql.DB
+	testSQLiteConnStr = "file:gob?mode=memory&cache=shared"
+	testSQLiteArgs    = ConnArgs{
+		ConnStr:      testSQLiteConnStr,
+		IdleConns:    1,
+		OpenConns:    1,
+		ConnIdleTime: 3 * time.Second,
+		ConnLifeTime: 3 * time.Second,
+	}
+)
+
+func init() {
+	if err := setupSQLiteDB(); err != nil {
+		log.Fatalf("setup sqlite; err: %v", err)
+	}
+}
+
+func setupSQLiteDB() error {
+	if testSQLiteDB == nil {
+		// in-memory database lives as long as a conn is open
+		sqliteDB, err := sql.Open("sqlite3", testSQLiteConnStr)
+		if err != nil {
+			return fmt.Errorf("connect to SQLite: %w", err)
+		}
+		sqliteDB.SetMaxOpenConns(1)
+		sqliteDB.SetConnMaxLifetime(0)
+		sqliteDB.SetConnMaxIdleTime(0)
+
+		testSQLiteDB = sqliteDB
+	}
+
+	if _, err := testSQLiteDB.Exec("DROP TABLE IF EXISTS students"); err != nil {
+		return fmt.Errorf("drop table: %w", err)
+	}
+
+	if _, err := testSQLiteDB.Exec(`CREATE TABLE students(
+		id INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		age INT,
+		profile JSON,
+		subjects JSON,
+		birthday TIMESTAMP,
+		UNIQUE (name)
+		)`); err != nil {
+		return fmt.Errorf("create table: %w", err)
+	}
+
+	return nil
+}
+
+func testGenStudentRowsSQLite(count int) (rows []Row) {
+	return testGenStudentRowsMySQL(count)
+}
+
+func testVerifyStudentRowsSQLite(t *testing.T, rows []Row) {
+	for _, want := range rows {
+		var (
+			name     string
+			age      int
+			profile  string
+			subjects string
+		)
+
+		err := testSQLiteDB.QueryRow("SELECT name, age, profile, subjects FROM students WHERE name=?", want.Value("name")).
+			Scan(&name, &age, &profile, &subjects)
+		if err != nil {
+			t.Fatalf("read student row: %s; err: %v", want.Value("name"), err)
+		}
+
+		got := NewRow()
+		got.Add("name", name)
+		got.Add("age", age)
+		got.Add("profile", profile)
+		got.Add("subjects", subjects)
+		got.Add("birthday", want.Value("birthday"))
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("student row: %s got: %+v want: %+v", want.Value("name"), got, want)
+		}
+	}
+}
+
+func TestNewSQLite(t *testing.T) {
+	db, err := newSQLite(testSQLiteArgs)
+	if err != nil {
+		t.Fatalf("init SQLite; err: %v", err)
+	}
+
+	db.Close()
+}
+
+func TestUpsertSQLite(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup SQLite err: %v", err)
+	}
+
+	db, err := newSQLite(testSQLiteArgs)
+	if err != nil {
+		t.Fatalf("init SQLite err: %v", err)
+	}
+	defer db.Close()
+
+	testUpsertDB(t, db, testGenStudentRowsSQLite, testVerifyStudentRowsSQLite)
+
+	t.Run("invalidConflictTarget", func(t *testing.T) {
+		for _, args := range []UpsertArgs{
+			{Keys: []string{"name"}, Constraint: "students_name_key"},
+			{Keys: []string{"name); DROP TABLE students; --"}},
+			{Keys: []string{"name"}, KeyPredicate: "age > 0; DROP TABLE students"},
+		} {
+			args.ConflictAction = ConflictActionUpdate
+			args.Model = "students"
+			args.keySet = utils.NewStringSet(args.Keys...)
+			args.Rows = testGenStudentRowsSQLite(1)
+
+			if err := db.Upsert(context.Background(), args); !errors.Is(err, ErrInvalidConflictTarget) {
+				t.Fatalf("upsert with %+v got: %v want: %v", args, err, ErrInvalidConflictTarget)
+			}
+		}
+
+		if err := db.Upsert(context.Background(), UpsertArgs{
+			ConflictAction: ConflictActionUpdate,
+			Model:          "students",
+			Rows:           testGenStudentRowsSQLite(1),
+		}); !errors.Is(err, ErrEmptykeys) {
+			t.Fatalf("upsert without keys got: %v want: %v", err, ErrEmptykeys)
+		}
+	})
+
+	t.Run("partialIndex", func(t *testing.T) {
+		for _, sql := range []string{
+			"DROP TABLE IF EXISTS graduates",
+			"CREATE TABLE graduates(name TEXT NOT NULL, age INT, active INT NOT NULL)",
+			"CREATE UNIQUE INDEX graduates_active_name ON graduates(name) WHERE active = 1",
+		} {
+			if _, err := testSQLiteDB.Exec(sql); err != nil {
+				t.Fatalf("execute %s err: %v", sql, err)
+			}
+		}
+		defer testSQLiteDB.Exec("DROP TABLE graduates")
+
+		for _, age := range []int{30, 31} {
+			if err := db.Upsert(context.Background(), UpsertArgs{
+				ConflictAction: ConflictActionUpdate,
+				Model:          "graduates",
+				Keys:           []string{"name"},
+				KeyPredicate:   "active = 1",
+				keySet:         utils.NewStringSet("name"),
+				Rows:           []Row{{"name": "name-0", "age": age, "active": 1}},
+			}); err != nil {
+				t.Fatalf("upsert rows on partial index err: %v", err)
+			}
+		}
+
+		var count, age int
+		if err := testSQLiteDB.QueryRow("SELECT COUNT(*), MAX(age) FROM graduates").Scan(&count, &age); err != nil {
+			t.Fatalf("query graduates err: %v", err)
+		}
+
+		if count != 1 || age != 31 {
+			t.Fatalf("graduates got: %d, age %d want: 1, age 31", count, age)
+		}
+	})
+}
+
+func TestRowToSQLSQLite(t *testing.T) {
+	wantSQLs := []string{
+		`INSERT INTO students("age","birthday","name","profile","subjects") VALUES(?,?,?,?,?) ON CONFLICT(name) DO UPDATE SET "age"=excluded."age","birthday"=excluded."birthday","profile"=excluded."profile","subjects"=excluded."subjects"`,
+		`INSERT INTO students("age","birthday","name","profile","subjects") VALUES(?,?,?,?,?) ON CONFLICT(name) DO NOTHING`,
+	}
+
+	wantArgs := [][]interface{}{
+		{0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`},
+		{0, nil, "name-0", `{"state": "state-0", "street": "street-0", "zipcode": 0}`, `["english", "calculus"]`},
+	}
+
+	rowToSQL := testDialectRowToSQL(DialectSQLite)
+	testRowToSQL(t, testGenStudentRowsSQLite, rowToSQL, wantSQLs, wantArgs)
+
+	t.Run("keyPredicate", func(t *testing.T) {
+		got, _ := rowToSQL(Row{"name": "name-0", "age": 0}, UpsertArgs{
+			ConflictAction: ConflictActionNothing,
+			Model:          "students",
+			Keys:           []string{"name"},
+			KeyPredicate:   "age > 0",
+			keySet:         utils.NewStringSet("name"),
+		})
+
+		if want := `INSERT INTO students("age","name") VALUES(?,?) ON CONFLICT(name) WHERE age > 0 DO NOTHING`; got != want {
+			t.Fatalf("sql got: %s want: %s", got, want)
+		}
+	})
+
+	t.Run("keysOnly", func(t *testing.T) {
+		got, _ := rowToSQL(Row{"name": "name-0"}, UpsertArgs{
+			ConflictAction: ConflictActionUpdate,
+			Model:          "students",
+			Keys:           []string{"name"},
+		})
+
+		if want := `INSERT INTO students("name") VALUES(?) ON CONFLICT(name) DO NOTHING`; got != want {
+			t.Fatalf("sql got: %s want: %s", got, want)
+		}
+	})
+}
+
+func TestMetadataSQLite(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup SQLite err: %v", err)
+	}
+
+	db, err := newSQLite(testSQLiteArgs)
+	if err != nil {
+		t.Fatalf("init SQLite err: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.(MetadataProvider).Metadata(context.Background(), "teachers"); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("metadata of unknown model got: %v want: %v", err, ErrModelNotFound)
+	}
+
+	got, err := db.(MetadataProvider).Metadata(context.Background(), "students")
+	if err != nil {
+		t.Fatalf("metadata err: %v", err)
+	}
+
+	want := &Metadata{
+		Model: "students",
+		Columns: []Column{
+			{Name: "id", Type: "INTEGER", Nullable: true, typ: columnType{kind: kindInt, bits: 64}},
+			{Name: "name", Type: "VARCHAR(255)", typ: columnType{kind: kindString}},
+			{Name: "age", Type: "INT", Nullable: true, typ: columnType{kind: kindInt, bits: 64}},
+			{Name: "profile", Type: "JSON", Nullable: true, typ: columnType{kind: kindJSON}},
+			{Name: "subjects", Type: "JSON", Nullable: true, typ: columnType{kind: kindJSON}},
+			{Name: "birthday", Type: "TIMESTAMP", Nullable: true, typ: columnType{kind: kindTime}},
+		},
+		PrimaryKey: []string{"id"},
+		UniqueKeys: [][]string{{"name"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("metadata got: %+v want: %+v", got, want)
+	}
+}
+
+func TestPingStatsSQLite(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup SQLite err: %v", err)
+	}
+
+	gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+
+	if err := gob.Ping(context.Background()); err != nil {
+		t.Fatalf("ping err: %v", err)
+	}
+
+	if stats := gob.Stats(); stats.Open < 1 || stats.Open != stats.Idle+stats.InUse {
+		t.Fatalf("stats got: %+v want: open connections idle or in use", stats)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := gob.Ping(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ping of canceled ctx got: %v want: %v", err, context.Canceled)
+	}
+
+	gob.Close()
+	if stats := gob.Stats(); stats != (PoolStats{}) {
+		t.Fatalf("stats after close got: %+v want: zero", stats)
+	}
+}
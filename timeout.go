@@ -0,0 +1,56 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// contextError of operation whose context is done; matches ErrTimeout or ErrCanceled and the error of context
+type contextError struct {
+	op    string // e.g. batch 3 of model 'students'
+	kind  error  // ErrTimeout or ErrCanceled
+	cause error  // context.DeadlineExceeded or context.Canceled
+	err   error  // of operation
+}
+
+func (e *contextError) Error() string {
+	if e.kind == ErrTimeout {
+		return fmt.Sprintf("gob: %s timed out; %v", e.op, e.err)
+	}
+
+	return fmt.Sprintf("gob: %s canceled; %v", e.op, e.err)
+}
+
+func (e *contextError) Is(target error) bool {
+	return target == e.kind || target == e.cause
+}
+
+func (e *contextError) Unwrap() error {
+	return e.err
+}
+
+// ctxError marks err of op as ErrTimeout or ErrCanceled if ctx is done; other errors and errors already marked
+// are returned as is
+func ctxError(ctx context.Context, op string, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ErrTimeout) || errors.Is(err, ErrCanceled) {
+		return err
+	}
+
+	e := &contextError{op: op, kind: ErrCanceled, cause: context.Canceled, err: err}
+	if ctx.Err() == context.DeadlineExceeded {
+		e.kind, e.cause = ErrTimeout, context.DeadlineExceeded
+	}
+
+	return e
+}
+
+// withTimeout returns ctx canceled after timeout; ctx as is if timeout is 0
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,109 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCtxError(t *testing.T) {
+	opErr := errors.New("failed")
+	if err := ctxError(context.Background(), "batch", opErr); err != opErr {
+		t.Fatalf("err of live ctx got: %v want: %v", err, opErr)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-timeoutCtx.Done()
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, test := range []struct {
+		ctx     context.Context
+		want    []error
+		notWant error
+		class   string
+	}{
+		{timeoutCtx, []error{ErrTimeout, context.DeadlineExceeded, opErr}, ErrCanceled, ErrorClassTimeout},
+		{canceledCtx, []error{ErrCanceled, context.Canceled, opErr}, ErrTimeout, ErrorClassCanceled},
+	} {
+		err := ctxError(test.ctx, "batch 1 of model 'students'", opErr)
+		for _, want := range test.want {
+			if !errors.Is(err, want) {
+				t.Fatalf("err %v is not %v", err, want)
+			}
+		}
+
+		if errors.Is(err, test.notWant) {
+			t.Fatalf("err %v is %v", err, test.notWant)
+		}
+
+		if class := ErrorClass(err); class != test.class {
+			t.Fatalf("class of %v got: %s want: %s", err, class, test.class)
+		}
+
+		// marked once
+		if again := ctxError(test.ctx, "batch", err); again != err {
+			t.Fatalf("err marked again got: %v want: %v", again, err)
+		}
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	args := UpsertArgs{
+		Model:          "students",
+		Rows:           testGenStudentRowsMySQL(10),
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	}
+
+	gob, err := New(WithDBProvider(DBProviderMemory), WithTimeouts(time.Nanosecond, 0))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	err = gob.Upsert(context.Background(), args)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCanceled) {
+		t.Fatalf("upsert exceeding batch timeout got: %v want: %v", err, ErrTimeout)
+	}
+
+	if !strings.Contains(err.Error(), "batch 1 of model 'students' timed out") {
+		t.Fatalf("err got: %v want: batch 1 of model 'students' timed out", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := gob.Upsert(ctx, args); !errors.Is(err, ErrCanceled) || errors.Is(err, ErrTimeout) {
+		t.Fatalf("upsert of canceled ctx got: %v want: %v", err, ErrCanceled)
+	}
+
+	if _, err := New(WithDBProvider(DBProviderMemory), WithTimeouts(-1, 0)); err == nil {
+		t.Fatalf("init gob with negative timeout; want err")
+	}
+}
+
+func TestTimeoutsSQLite(t *testing.T) {
+	if err := setupSQLiteDB(); err != nil {
+		t.Fatalf("setup SQLite err: %v", err)
+	}
+
+	gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr), WithTimeouts(time.Hour, time.Nanosecond))
+	if err != nil {
+		t.Fatalf("init gob; err: %v", err)
+	}
+	defer gob.Close()
+
+	err = gob.Upsert(context.Background(), UpsertArgs{
+		Model:          "students",
+		Rows:           testGenStudentRowsSQLite(1),
+		Keys:           []string{"name"},
+		ConflictAction: ConflictActionUpdate,
+	})
+	if !errors.Is(err, ErrTimeout) || !strings.Contains(err.Error(), "statement timed out") {
+		t.Fatalf("upsert exceeding statement timeout got: %v want: statement %v", err, ErrTimeout)
+	}
+}
@@ -0,0 +1,53 @@
+package gob
+
+import "context"
+
+// Names of spans started by Gob and providers
+const (
+	SpanUpsert = "gob.upsert" // Gob.Upsert of all rows
+	SpanBatch  = "gob.batch"  // upsert of batch of rows
+	SpanCommit = "gob.commit" // commit of transaction of batch; execution of statements of batch by Cassandra
+)
+
+// Tracer starts spans around upserts, batches and commits with attributes model, rows, batch and provider;
+// the shape of trace.Tracer of OpenTelemetry so either may be adapted to the other
+type Tracer interface {
+	// Start span name as child of span of ctx; returned ctx holds the span
+	Start(ctx context.Context, name string, attrs ...Field) (context.Context, Span)
+}
+
+// Span of Tracer
+type Span interface {
+	// SetAttributes of span
+	SetAttributes(attrs ...Field)
+
+	// RecordError of failed operation of span
+	RecordError(err error)
+
+	// End span
+	End()
+}
+
+// nopTracer starts spans doing nothing; default Tracer of Gob
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, name string, attrs ...Field) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(attrs ...Field) {}
+
+func (nopSpan) RecordError(err error) {}
+
+func (nopSpan) End() {}
+
+// endSpan records err if any and ends span
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	span.End()
+}
@@ -0,0 +1,158 @@
+package gob
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type testSpanKey struct{}
+
+// testSpan recorded by testTracer
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...Field) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(ctx context.Context, name string, attrs ...Field) (context.Context, Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	span := &testSpan{name: name, attrs: make(map[string]interface{})}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.parent = parent.name
+	}
+	span.SetAttributes(attrs...)
+	tr.spans = append(tr.spans, span)
+
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+// names of spans as parent/name
+func (tr *testTracer) names() (names []string) {
+	for _, span := range tr.spans {
+		names = append(names, span.parent+"/"+span.name)
+		if !span.ended {
+			names = append(names, "unended")
+		}
+	}
+
+	return names
+}
+
+func TestTracer(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		tracer := &testTracer{}
+		gob, err := New(WithDBProvider(DBProviderMemory), WithBatchSize(10), WithTracer(tracer))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(15),
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		want := []string{"/gob.upsert", "gob.upsert/gob.batch", "gob.upsert/gob.batch"}
+		if got := tracer.names(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("spans got: %v want: %v", got, want)
+		}
+
+		wantAttrs := map[string]interface{}{"model": "students", "rows": 5, "batch": 2, "provider": "memory"}
+		if got := tracer.spans[2].attrs; !reflect.DeepEqual(got, wantAttrs) {
+			t.Fatalf("attributes of batch got: %v want: %v", got, wantAttrs)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		tracer := &testTracer{}
+		gob, err := New(WithDBProvider(DBProviderMemory), WithTracer(tracer))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		err = gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsMySQL(1),
+			Constraint:     "students_pkey",
+			ConflictAction: ConflictActionUpdate,
+		})
+		if !errors.Is(err, ErrInvalidConflictTarget) {
+			t.Fatalf("upsert err got: %v want: %v", err, ErrInvalidConflictTarget)
+		}
+
+		for _, span := range tracer.spans {
+			if span.err != err {
+				t.Fatalf("error of span %s got: %v want: %v", span.name, span.err, err)
+			}
+		}
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		if err := setupSQLiteDB(); err != nil {
+			t.Fatalf("setup sqlite; err: %v", err)
+		}
+
+		tracer := &testTracer{}
+		gob, err := New(WithDBProvider(DBProviderSQLite), WithDBConnStr(testSQLiteConnStr), WithTracer(tracer))
+		if err != nil {
+			t.Fatalf("init gob; err: %v", err)
+		}
+		defer gob.Close()
+
+		if err := gob.Upsert(context.Background(), UpsertArgs{
+			Model:          "students",
+			Rows:           testGenStudentRowsSQLite(2),
+			Keys:           []string{"name"},
+			ConflictAction: ConflictActionUpdate,
+		}); err != nil {
+			t.Fatalf("upsert rows err: %v", err)
+		}
+
+		want := []string{"/gob.upsert", "gob.upsert/gob.batch", "gob.batch/gob.commit"}
+		if got := tracer.names(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("spans got: %v want: %v", got, want)
+		}
+
+		wantAttrs := map[string]interface{}{"model": "students", "rows": 2, "provider": "sqlite"}
+		if got := tracer.spans[2].attrs; !reflect.DeepEqual(got, wantAttrs) {
+			t.Fatalf("attributes of commit got: %v want: %v", got, wantAttrs)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if _, err := New(WithDBProvider(DBProviderMemory), WithTracer(nil)); err == nil {
+			t.Fatalf("init gob with nil tracer; want err")
+		}
+	})
+}
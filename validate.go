@@ -0,0 +1,406 @@
+package gob
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// columnKind classifies database types for validation
+type columnKind int
+
+const (
+	kindAny columnKind = iota // type not validated
+	kindString
+	kindInt
+	kindFloat
+	kindBool
+	kindTime
+	kindDate
+	kindUUID
+	kindBytes
+	kindJSON
+	kindList
+	kindMap
+)
+
+// columnType of database column
+type columnType struct {
+	kind columnKind
+	bits int         // size of integer types
+	key  *columnType // key type of maps
+	elem *columnType // element type of lists and maps
+}
+
+// dateLayout of date values passed as string
+const dateLayout = "2006-01-02"
+
+// RowError lists problems of a row rejected by validation
+type RowError struct {
+	Index    int      // index of row in UpsertArgs.Rows
+	Problems []string // problems found in row
+}
+
+// ValidationError reports all rows rejected by validation in a batch
+type ValidationError struct {
+	Model string     // table name
+	Rows  []RowError // rejected rows
+}
+
+func (err *ValidationError) Error() string {
+	var rows []string
+	for _, row := range err.Rows {
+		rows = append(rows, fmt.Sprintf("row %d: %s", row.Index, strings.Join(row.Problems, ", ")))
+	}
+
+	return fmt.Sprintf("%s model '%s' %s", ErrInvalidRows.Error(), err.Model, strings.Join(rows, "; "))
+}
+
+// Is reports whether target is ErrInvalidRows
+func (err *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRows
+}
+
+// validateRows checks rows against columns of model and coerces values to column types;
+// offset is the index of first row in UpsertArgs.Rows
+func validateRows(metadata *Metadata, rows []Row, offset int) ([]Row, error) {
+	var (
+		columns   = metadata.columnSet()
+		validRows = make([]Row, len(rows))
+		rowErrs   []RowError
+	)
+
+	for idx, row := range rows {
+		if row.Len() == 0 {
+			continue // ignore empty row
+		}
+
+		var (
+			problems []string
+			coerced  Row
+		)
+
+		for _, name := range row.Columns() {
+			column, ok := columns[name]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("column '%s' not found in model", name))
+				continue
+			}
+
+			value := row.Value(name)
+			if value == nil {
+				if !column.Nullable {
+					problems = append(problems, fmt.Sprintf("column '%s' is NOT NULL", name))
+				}
+				continue
+			}
+
+			v, err := coerce(value, column.typ)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("column '%s' of type %s: %v", name, column.Type, err))
+				continue
+			}
+
+			if changed(value, v) {
+				if coerced == nil {
+					coerced = copyRow(row)
+				}
+				coerced.Add(name, v)
+			}
+		}
+
+		for _, column := range metadata.Columns {
+			if _, ok := row[column.Name]; !ok && !column.Nullable && !column.Default {
+				problems = append(problems, fmt.Sprintf("column '%s' is NOT NULL and has no default", column.Name))
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrs = append(rowErrs, RowError{Index: offset + idx, Problems: problems})
+			continue
+		}
+
+		validRows[idx] = row
+		if coerced != nil {
+			validRows[idx] = coerced
+		}
+	}
+
+	if len(rowErrs) > 0 {
+		return nil, &ValidationError{Model: metadata.Model, Rows: rowErrs}
+	}
+
+	return validRows, nil
+}
+
+// changed reports whether coerced value differs from value
+func changed(value, coerced interface{}) bool {
+	typ := reflect.TypeOf(value)
+	if typ != reflect.TypeOf(coerced) {
+		return true
+	}
+
+	// slices and maps are returned as is
+	if !typ.Comparable() {
+		return false
+	}
+
+	return value != coerced
+}
+
+func copyRow(row Row) Row {
+	c := make(Row, row.Len())
+	for column, value := range row {
+		c[column] = value
+	}
+
+	return c
+}
+
+// coerce converts value to Go type accepted by the drivers for column type
+func coerce(value interface{}, typ columnType) (interface{}, error) {
+	switch typ.kind {
+	case kindString:
+		return coerceString(value)
+	case kindInt:
+		return coerceInt(value, typ.bits)
+	case kindFloat:
+		return coerceFloat(value)
+	case kindBool:
+		return coerceBool(value)
+	case kindTime:
+		return coerceTime(value)
+	case kindDate:
+		return coerceDate(value)
+	case kindUUID:
+		return coerceUUID(value)
+	case kindBytes:
+		return coerceBytes(value)
+	case kindJSON:
+		return coerceJSON(value)
+	case kindList:
+		return coerceKind(value, reflect.Slice, reflect.Array)
+	case kindMap:
+		return coerceKind(value, reflect.Map)
+	}
+
+	return value, nil
+}
+
+func mismatch(value interface{}) error {
+	return fmt.Errorf("incompatible value %v of %T", value, value)
+}
+
+// coerceString accepts values of string kind and []byte; other types e.g. fmt.Stringer are rejected as drivers do
+// not bind them as text
+func coerceString(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+
+	if reflect.ValueOf(value).Kind() == reflect.String {
+		return reflect.ValueOf(value).String(), nil
+	}
+
+	return nil, mismatch(value)
+}
+
+func coerceInt(value interface{}, bits int) (interface{}, error) {
+	var n int64
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("value %v out of range", value)
+		}
+		n = int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); f != math.Trunc(f) {
+			return nil, mismatch(value)
+		}
+		n = int64(rv.Float())
+	case reflect.String:
+		i, err := strconv.ParseInt(strings.TrimSpace(rv.String()), 10, 64)
+		if err != nil {
+			return nil, mismatch(value)
+		}
+		n = i
+	default:
+		return nil, mismatch(value)
+	}
+
+	switch bits {
+	case 8:
+		if n < math.MinInt8 || n > math.MaxInt8 {
+			return nil, fmt.Errorf("value %v out of range", value)
+		}
+		return int8(n), nil
+	case 16:
+		if n < math.MinInt16 || n > math.MaxInt16 {
+			return nil, fmt.Errorf("value %v out of range", value)
+		}
+		return int16(n), nil
+	case 32:
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("value %v out of range", value)
+		}
+		return int32(n), nil
+	}
+
+	return n, nil
+}
+
+func coerceFloat(value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			return nil, mismatch(value)
+		}
+		return f, nil
+	}
+
+	return nil, mismatch(value)
+}
+
+func coerceBool(value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := rv.Int(); n == 0 || n == 1 {
+			return n == 1, nil
+		}
+	case reflect.String:
+		if b, err := strconv.ParseBool(strings.TrimSpace(rv.String())); err == nil {
+			return b, nil
+		}
+	}
+
+	return nil, mismatch(value)
+}
+
+func coerceTime(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		return *v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+		if err != nil {
+			return nil, mismatch(value)
+		}
+		return t, nil
+	}
+
+	return nil, mismatch(value)
+}
+
+func coerceDate(value interface{}) (interface{}, error) {
+	var t time.Time
+
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		t = *v
+	case string:
+		d, err := time.Parse(dateLayout, strings.TrimSpace(v))
+		if err != nil {
+			return nil, mismatch(value)
+		}
+		t = d
+	default:
+		return nil, mismatch(value)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func coerceUUID(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case gocql.UUID:
+		return v, nil
+	case [16]byte:
+		return gocql.UUID(v), nil
+	case []byte:
+		u, err := gocql.UUIDFromBytes(v)
+		if err != nil {
+			return nil, mismatch(value)
+		}
+		return u, nil
+	case string:
+		u, err := gocql.ParseUUID(strings.TrimSpace(v))
+		if err != nil {
+			return nil, mismatch(value)
+		}
+		return u, nil
+	}
+
+	return nil, mismatch(value)
+}
+
+func coerceBytes(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+
+	return nil, mismatch(value)
+}
+
+func coerceJSON(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !json.Valid([]byte(v)) {
+			return nil, fmt.Errorf("invalid JSON %s", v)
+		}
+		return v, nil
+	case []byte:
+		if !json.Valid(v) {
+			return nil, fmt.Errorf("invalid JSON %s", v)
+		}
+		return v, nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal JSON: %w", err)
+	}
+
+	return string(b), nil
+}
+
+func coerceKind(value interface{}, kinds ...reflect.Kind) (interface{}, error) {
+	kind := reflect.ValueOf(value).Kind()
+	for _, k := range kinds {
+		if kind == k {
+			return value, nil
+		}
+	}
+
+	return nil, mismatch(value)
+}
@@ -0,0 +1,146 @@
+package gob
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+var testStudentsMetadata = &Metadata{
+	Model: "students",
+	Columns: []Column{
+		{Name: "id", Type: "uuid", typ: columnType{kind: kindUUID}},
+		{Name: "name", Type: "text", typ: columnType{kind: kindString}},
+		{Name: "age", Type: "int", Nullable: true, typ: columnType{kind: kindInt, bits: 32}},
+		{Name: "birthday", Type: "date", Nullable: true, typ: columnType{kind: kindDate}},
+		{Name: "profile", Type: "jsonb", Nullable: true, typ: columnType{kind: kindJSON}},
+		{Name: "subjects", Type: "list<text>", Nullable: true, typ: columnType{kind: kindList}},
+		{Name: "created_at", Type: "timestamp", Default: true, typ: columnType{kind: kindTime}},
+	},
+}
+
+func TestValidateRows(t *testing.T) {
+	t.Run("coerce", func(t *testing.T) {
+		id := "7f4df5a4-25c0-11eb-adc1-0242ac120002"
+		row := Row{
+			"id":       id,
+			"name":     "name-0",
+			"age":      int64(10),
+			"birthday": time.Date(2000, 1, 2, 15, 4, 5, 0, time.UTC),
+			"profile":  studentProfile{State: "state-0"},
+			"subjects": []string{"english"},
+		}
+
+		got, err := validateRows(testStudentsMetadata, []Row{row}, 0)
+		if err != nil {
+			t.Fatalf("validate rows err: %v", err)
+		}
+
+		uuid, _ := gocql.ParseUUID(id)
+		want := Row{
+			"id":       uuid,
+			"name":     "name-0",
+			"age":      int32(10),
+			"birthday": time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC),
+			"profile":  `{"state":"state-0","street":"","zipcode":0}`,
+			"subjects": []string{"english"},
+		}
+
+		if !reflect.DeepEqual(got[0], want) {
+			t.Fatalf("row got: %v want: %v", got[0], want)
+		}
+
+		// rows of caller are not modified
+		if row.Value("id") != id {
+			t.Fatalf("row modified by coercion: %v", row)
+		}
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		rows := []Row{
+			{"id": "7f4df5a4-25c0-11eb-adc1-0242ac120002", "name": "name-0"},
+			{"id": "invalid", "age": int64(1 << 40), "grade": 3},
+			{"id": "7f4df5a4-25c0-11eb-adc1-0242ac120002", "name": nil, "birthday": "01/02/2000"},
+		}
+
+		_, err := validateRows(testStudentsMetadata, rows, 10)
+		if !errors.Is(err, ErrInvalidRows) {
+			t.Fatalf("validate rows got: %v want: %v", err, ErrInvalidRows)
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("validate rows got: %T want: %T", err, validationErr)
+		}
+
+		want := []RowError{
+			{
+				Index: 11,
+				Problems: []string{
+					"column 'age' of type int: value 1099511627776 out of range",
+					"column 'grade' not found in model",
+					"column 'id' of type uuid: incompatible value invalid of string",
+					"column 'name' is NOT NULL and has no default",
+				},
+			},
+			{
+				Index: 12,
+				Problems: []string{
+					"column 'birthday' of type date: incompatible value 01/02/2000 of string",
+					"column 'name' is NOT NULL",
+				},
+			},
+		}
+
+		if !reflect.DeepEqual(validationErr.Rows, want) {
+			t.Fatalf("row errors got: %+v want: %+v", validationErr.Rows, want)
+		}
+	})
+}
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		typ   columnType
+		want  interface{}
+		valid bool
+	}{
+		{name: "stringToInt", value: "42", typ: columnType{kind: kindInt, bits: 64}, want: int64(42), valid: true},
+		{name: "intToSmallInt", value: 42, typ: columnType{kind: kindInt, bits: 16}, want: int16(42), valid: true},
+		{name: "intOutOfRange", value: 1 << 20, typ: columnType{kind: kindInt, bits: 8}},
+		{name: "fractionToInt", value: 1.5, typ: columnType{kind: kindInt, bits: 32}},
+		{name: "intToFloat", value: 3, typ: columnType{kind: kindFloat}, want: float64(3), valid: true},
+		{name: "stringToBool", value: "true", typ: columnType{kind: kindBool}, want: true, valid: true},
+		{name: "intToBool", value: 2, typ: columnType{kind: kindBool}},
+		{name: "stringToTime", value: "2000-01-02T15:04:05Z", typ: columnType{kind: kindTime}, want: time.Date(2000, 1, 2, 15, 4, 5, 0, time.UTC), valid: true},
+		{name: "stringToDate", value: "2000-01-02", typ: columnType{kind: kindDate}, want: time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), valid: true},
+		{name: "intToString", value: 1, typ: columnType{kind: kindString}},
+		{name: "bytesToString", value: []byte("name"), typ: columnType{kind: kindString}, want: "name", valid: true},
+		{name: "stringKindToString", value: ConflictActionUpdate, typ: columnType{kind: kindString}, want: "update", valid: true},
+		{name: "stringerToString", value: time.Second, typ: columnType{kind: kindString}},
+		{name: "invalidJSON", value: "{", typ: columnType{kind: kindJSON}},
+		{name: "mapToList", value: map[string]string{}, typ: columnType{kind: kindList}},
+		{name: "any", value: struct{}{}, typ: columnType{kind: kindAny}, want: struct{}{}, valid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := coerce(test.value, test.typ)
+			if test.valid && err != nil {
+				t.Fatalf("coerce %v err: %v", test.value, err)
+			}
+
+			if !test.valid && err == nil {
+				t.Fatalf("coerce %v; want err", test.value)
+			}
+
+			if test.valid && !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("coerce got: %v (%T) want: %v (%T)", got, got, test.want, test.want)
+			}
+		})
+	}
+}